@@ -0,0 +1,230 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"merkle-generator/merkle"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/spf13/cobra"
+)
+
+// maxLeafLineSize bounds a single line of a leaves file, which easily fits a raw string leaf
+const maxLeafLineSize = 1024 * 1024
+
+// parseFunc turns one input into a leaf, using location (e.g. "argument 2") in errors
+type parseFunc func(input, location string) (common.Hash, error)
+
+// addLeavesFileFlag registers --leaves-file on a command
+func addLeavesFileFlag(cmd *cobra.Command, usage string) {
+	cmd.Flags().String("leaves-file", "", usage)
+}
+
+// addLeafFormatFlags registers --leaf-format and --allow-mixed on a command
+func addLeafFormatFlags(cmd *cobra.Command) {
+	cmd.Flags().String("leaf-format", merkle.AutoLeafFormat.String(), "Leaf input format: auto, raw (hash the input), hex (bytes32) or address (padded to bytes32)")
+	cmd.Flags().Bool("allow-mixed", false, "Accept both raw and hex leaves when --leaf-format is auto")
+}
+
+// leafParser parses leaves following --leaf-format
+// In auto mode every leaf must have the same detected format unless --allow-mixed is set.
+type leafParser struct {
+	format     merkle.LeafFormat
+	allowMixed bool
+	hashFunc   merkle.HashFunc
+
+	// The first detected format and where it was seen, for reporting mixed inputs
+	firstFormat   merkle.LeafFormat
+	firstLocation string
+}
+
+// newLeafParser creates a parser from the command's leaf format flags
+func newLeafParser(cmd *cobra.Command, hashFunc merkle.HashFunc) (*leafParser, error) {
+	formatName, _ := cmd.Flags().GetString("leaf-format")
+	allowMixed, _ := cmd.Flags().GetBool("allow-mixed")
+
+	format, err := merkle.LeafFormatByName(formatName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &leafParser{
+		format:     format,
+		allowMixed: allowMixed,
+		hashFunc:   hashFunc,
+	}, nil
+}
+
+// parse turns one input into a leaf
+func (p *leafParser) parse(input, location string) (common.Hash, error) {
+	leaf, format, err := merkle.ParseLeaf(strings.TrimSpace(input), p.format, p.hashFunc)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("invalid leaf at %s: %v", location, err)
+	}
+
+	if p.firstLocation == "" {
+		p.firstFormat = format
+		p.firstLocation = location
+	} else if format != p.firstFormat && !p.allowMixed {
+		return common.Hash{}, fmt.Errorf("leaf at %s is %s but leaf at %s is %s; set --leaf-format or pass --allow-mixed",
+			location, format, p.firstLocation, p.firstFormat)
+	}
+
+	return leaf, nil
+}
+
+// parseHash parses a bytes32 that is always hex, such as a root or a proof element
+func parseHash(input, location string) (common.Hash, error) {
+	hash, err := merkle.HexToHash(strings.TrimSpace(input))
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("invalid hash at %s: %v", location, err)
+	}
+	return hash, nil
+}
+
+// parseArgs parses command line arguments, where first is the position of args[0] on the command line
+func parseArgs(args []string, first int, parse parseFunc) ([]common.Hash, error) {
+	leaves := make([]common.Hash, len(args))
+	for i, arg := range args {
+		leaf, err := parse(arg, fmt.Sprintf("argument %d", first+i))
+		if err != nil {
+			return nil, err
+		}
+		leaves[i] = leaf
+	}
+	return leaves, nil
+}
+
+// collectLeaves parses the leaves given as arguments followed by those in --leaves-file, if set
+func collectLeaves(cmd *cobra.Command, args []string, first int, parse parseFunc) ([]common.Hash, error) {
+	leaves, err := parseArgs(args, first, parse)
+	if err != nil {
+		return nil, err
+	}
+
+	path, _ := cmd.Flags().GetString("leaves-file")
+	if path == "" {
+		return leaves, nil
+	}
+
+	fileLeaves, err := readLeavesFile(path, parse)
+	if err != nil {
+		return nil, err
+	}
+	return append(leaves, fileLeaves...), nil
+}
+
+// readLeavesFile reads leaves from a file, or from stdin for "-"
+// The file holds one leaf per line or a JSON array of strings. Leaves are parsed while reading,
+// so only the resulting hashes are kept in memory.
+func readLeavesFile(path string, parse parseFunc) ([]common.Hash, error) {
+	var r io.Reader = os.Stdin
+	name := "stdin"
+	if path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open leaves file: %w", err)
+		}
+		defer file.Close()
+		r = file
+		name = path
+	}
+
+	br := bufio.NewReaderSize(r, 64*1024)
+	first, skipped, err := peekNonSpace(br)
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read leaves file: %w", err)
+	}
+
+	if first == '[' {
+		return readJSONLeaves(br, name, parse)
+	}
+	return readLineLeaves(br, name, skipped, parse)
+}
+
+// peekNonSpace skips leading whitespace and returns the next byte without consuming it,
+// along with the number of lines skipped
+func peekNonSpace(br *bufio.Reader) (byte, int, error) {
+	lines := 0
+	for {
+		b, err := br.ReadByte()
+		if err != nil {
+			return 0, lines, err
+		}
+		switch b {
+		case '\n':
+			lines++
+			continue
+		case ' ', '\t', '\r':
+			continue
+		}
+		return b, lines, br.UnreadByte()
+	}
+}
+
+// readLineLeaves parses one leaf per line, skipping blank lines
+// Line numbers in errors start after the given number of already consumed lines
+func readLineLeaves(r io.Reader, name string, line int, parse parseFunc) ([]common.Hash, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxLeafLineSize)
+
+	var leaves []common.Hash
+	for scanner.Scan() {
+		line++
+		text := scanner.Text()
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+
+		leaf, err := parse(text, fmt.Sprintf("line %d of %s", line, name))
+		if err != nil {
+			return nil, err
+		}
+		leaves = append(leaves, leaf)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read leaves file: %w", err)
+	}
+
+	return leaves, nil
+}
+
+// readJSONLeaves parses a JSON array of strings one element at a time
+func readJSONLeaves(r io.Reader, name string, parse parseFunc) ([]common.Hash, error) {
+	decoder := json.NewDecoder(r)
+	if _, err := decoder.Token(); err != nil {
+		return nil, fmt.Errorf("invalid JSON leaves file: %w", err)
+	}
+
+	var leaves []common.Hash
+	for i := 0; decoder.More(); i++ {
+		var value string
+		if err := decoder.Decode(&value); err != nil {
+			return nil, fmt.Errorf("invalid JSON leaves file at element %d: %w", i, err)
+		}
+
+		leaf, err := parse(value, fmt.Sprintf("element %d of %s", i, name))
+		if err != nil {
+			return nil, err
+		}
+		leaves = append(leaves, leaf)
+	}
+
+	if _, err := decoder.Token(); err != nil {
+		return nil, fmt.Errorf("invalid JSON leaves file: %w", err)
+	}
+	if _, err := decoder.Token(); !errors.Is(err, io.EOF) {
+		return nil, errors.New("invalid JSON leaves file: unexpected data after the array")
+	}
+
+	return leaves, nil
+}
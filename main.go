@@ -1,182 +1,815 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"log"
 	"math/big"
+	"net/http"
 	"os"
-	"strings"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"merkle-generator/merkle"
+	"merkle-generator/util"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/spf13/cobra"
 )
 
 var rootCmd = &cobra.Command{
 	Use:   "merkle-generator",
 	Short: "A CLI tool for generating Merkle trees, roots, and proofs",
-	Long:  `A CLI tool that generates Merkle tree roots and proofs for given bytes32 leaves.`,
+	Long: `A CLI tool that generates Merkle tree roots and proofs for given bytes32 leaves.
+
+Every subcommand accepts --output json to print a {"ok", "command", "result", "error"} document
+instead of text. Failures exit with 2 (usage), 3 (invalid input), 4 (tree or proof generation),
+5 (proof not valid), 6 (output could not be written) or 7 (serve could not listen or stopped with
+an error).`,
+	PersistentPreRunE: validateOutputFormat,
+	SilenceErrors:     true,
+	SilenceUsage:      true,
+}
+
+// rootResult is the result of the root command
+type rootResult struct {
+	Root      string `json:"root"`
+	LeafCount int    `json:"leafCount"`
 }
 
 var generateRootCmd = &cobra.Command{
 	Use:   "root [leaf1] [leaf2] ...",
 	Short: "Generate Merkle root from leaves",
-	Long:  `Generate Merkle root from a list of hex-encoded bytes32 leaves.`,
-	Args:  cobra.MinimumNArgs(1),
-	Run: func(cmd *cobra.Command, args []string) {
-		leaves, err := parseLeaves(args)
+	Long: `Generate Merkle root from a list of hex-encoded bytes32 leaves.
+
+Leaves can also be read with --leaves-file, one per line or as a JSON array.
+Use --leaves-file - to read them from stdin.`,
+	Args: cobra.ArbitraryArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		hashFunc, opts, err := treeOptions(cmd)
 		if err != nil {
-			fmt.Printf("Error parsing leaves: %v\n", err)
-			os.Exit(1)
+			return fail(classUsage, "", err)
 		}
 
-		tree, err := merkle.NewMerkleTree(leaves)
+		parser, err := newLeafParser(cmd, hashFunc)
 		if err != nil {
-			fmt.Printf("Error creating Merkle tree: %v\n", err)
-			os.Exit(1)
+			return fail(classUsage, "", err)
+		}
+
+		leaves, err := collectLeaves(cmd, args, 1, parser.parse)
+		if err != nil {
+			return fail(classInput, "parsing leaves", err)
+		}
+		if len(leaves) == 0 {
+			return fail(classInput, "", errors.New("no leaves given"))
+		}
+
+		tree, err := merkle.NewMerkleTree(leaves, opts...)
+		if err != nil {
+			return fail(classTree, "creating Merkle tree", err)
 		}
 
 		root := tree.GenerateRoot()
-		fmt.Printf("Merkle Root: %s\n", root.Hex())
+		result := rootResult{Root: root.Hex(), LeafCount: tree.LeafCount()}
+		return writeResult(cmd, result, func(w io.Writer) {
+			fmt.Fprintf(w, "Merkle Root: %s\n", root.Hex())
+		})
 	},
 }
 
+// proofResult is the result of the proof command; the positional fields are only set for positional proofs
+type proofResult struct {
+	Target     string   `json:"target"`
+	Root       string   `json:"root"`
+	Proof      []string `json:"proof"`
+	LeafIndex  *int     `json:"leafIndex,omitempty"`
+	LeafCount  *int     `json:"leafCount,omitempty"`
+	Directions []bool   `json:"directions,omitempty"`
+	Path       string   `json:"path,omitempty"`
+}
+
 var generateProofCmd = &cobra.Command{
 	Use:   "proof [target] [leaf1] [leaf2] ...",
 	Short: "Generate Merkle proof for a target leaf",
-	Long:  `Generate Merkle proof for a target leaf given a list of all leaves.`,
-	Args:  cobra.MinimumNArgs(2),
-	Run: func(cmd *cobra.Command, args []string) {
+	Long: `Generate Merkle proof for a target leaf given a list of all leaves.
+
+The leaves can also be read with --leaves-file, one per line or as a JSON array.
+Use --leaves-file - to read them from stdin.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		hashFunc, opts, err := treeOptions(cmd)
+		if err != nil {
+			return fail(classUsage, "", err)
+		}
+
+		parser, err := newLeafParser(cmd, hashFunc)
+		if err != nil {
+			return fail(classUsage, "", err)
+		}
+
 		// Parse target the same way as leaves
-		targetLeaves, err := parseLeaves([]string{args[0]})
+		target, err := parser.parse(args[0], "argument 1")
 		if err != nil {
-			fmt.Printf("Error parsing target: %v\n", err)
-			os.Exit(1)
+			return fail(classInput, "parsing target", err)
 		}
-		target := targetLeaves[0]
 
-		leaves, err := parseLeaves(args[1:])
+		leaves, err := collectLeaves(cmd, args[1:], 2, parser.parse)
 		if err != nil {
-			fmt.Printf("Error parsing leaves: %v\n", err)
-			os.Exit(1)
+			return fail(classInput, "parsing leaves", err)
+		}
+		if len(leaves) == 0 {
+			return fail(classInput, "", errors.New("no leaves given"))
 		}
 
-		tree, err := merkle.NewMerkleTree(leaves)
+		tree, err := merkle.NewMerkleTree(leaves, opts...)
 		if err != nil {
-			fmt.Printf("Error creating Merkle tree: %v\n", err)
-			os.Exit(1)
+			return fail(classTree, "creating Merkle tree", err)
 		}
 
 		proof, err := tree.GenerateProof(target)
 		if err != nil {
-			fmt.Printf("Error generating proof: %v\n", err)
-			os.Exit(1)
+			return fail(classTree, "generating proof", err)
 		}
 
-		root := tree.GenerateRoot()
+		result := proofResult{
+			Target: target.Hex(),
+			Root:   tree.GenerateRoot().Hex(),
+			Proof:  formatProof(proof),
+		}
 
-		// Output as JSON for easy integration
-		result := map[string]interface{}{
-			"target": target.Hex(),
-			"root":   root.Hex(),
-			"proof":  formatProof(proof),
+		// Positional trees cannot be verified without the leaf position, so always include it for them
+		positional, _ := cmd.Flags().GetBool("positional")
+		pairs, _ := pairStrategy(cmd)
+		if positional || pairs == merkle.PositionalPairs {
+			positionalProof, err := tree.GeneratePositionalProof(tree.FindLeafIndices(target)[0])
+			if err != nil {
+				return fail(classTree, "generating positional proof", err)
+			}
+			leafCount := tree.LeafCount()
+			result.LeafIndex = &positionalProof.LeafIndex
+			result.LeafCount = &leafCount
+			result.Directions = positionalProof.Directions
+			result.Path = positionalProof.PathBitmap().String()
 		}
 
-		jsonOutput, _ := json.MarshalIndent(result, "", "  ")
-		fmt.Println(string(jsonOutput))
+		// The text output has always been JSON for easy integration
+		return writeResult(cmd, result, func(w io.Writer) {
+			writeJSON(w, result)
+		})
 	},
 }
 
+// verifyResult is the result of the verify command
+// Address and amount are only set when the leaf was recomputed from a per-recipient entry
+type verifyResult struct {
+	Valid   bool     `json:"valid"`
+	Root    string   `json:"root"`
+	Target  string   `json:"target"`
+	Proof   []string `json:"proof"`
+	Address string   `json:"address,omitempty"`
+	Amount  string   `json:"amount,omitempty"`
+}
+
 var verifyProofCmd = &cobra.Command{
 	Use:   "verify [root] [target] [proof1] [proof2] ...",
 	Short: "Verify a Merkle proof",
-	Long:  `Verify if a target leaf is in the Merkle tree using the provided proof.`,
-	Args:  cobra.MinimumNArgs(2),
-	Run: func(cmd *cobra.Command, args []string) {
-		// Parse root and target the same way as leaves
-		rootLeaves, err := parseLeaves([]string{args[0]})
+	Long: `Verify if a target leaf is in the Merkle tree using the provided proof.
+
+The proof elements can also be read with --leaves-file, one per line or as a JSON array.
+
+With --proof-file (- for stdin), everything is read from the JSON printed by the proof command,
+or from a per-recipient entry with address, amount and proof, whose leaf is recomputed as
+keccak256(abi.encodePacked(address, amount)). A root given as the only argument overrides
+the one in the file, which entries from a distribution artifact don't carry:
+  verify --proof-file entry.json 0x<root>
+Exits with status 5 when the proof is not valid.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if proofFile, _ := cmd.Flags().GetString("proof-file"); proofFile != "" {
+			return cobra.MaximumNArgs(1)(cmd, args)
+		}
+		return cobra.MinimumNArgs(2)(cmd, args)
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		hashFunc, opts, err := treeOptions(cmd)
 		if err != nil {
-			fmt.Printf("Error parsing root: %v\n", err)
-			os.Exit(1)
+			return fail(classUsage, "", err)
 		}
-		root := rootLeaves[0]
+		index, _ := cmd.Flags().GetInt("index")
+		leafCount, _ := cmd.Flags().GetInt("leaf-count")
+		pairs, _ := pairStrategy(cmd)
+		proofFilePath, _ := cmd.Flags().GetString("proof-file")
 
-		targetLeaves, err := parseLeaves([]string{args[1]})
+		parser, err := newLeafParser(cmd, hashFunc)
 		if err != nil {
-			fmt.Printf("Error parsing target: %v\n", err)
-			os.Exit(1)
+			return fail(classUsage, "", err)
 		}
-		target := targetLeaves[0]
 
+		var root, target common.Hash
 		var proof []common.Hash
-		if len(args) > 2 {
-			proof, err = parseLeaves(args[2:])
+		var result verifyResult
+		if proofFilePath != "" {
+			pf, err := readProofFile(proofFilePath)
+			if err != nil {
+				return fail(classInput, "reading proof file", err)
+			}
+			target, err = pf.target()
+			if err != nil {
+				return fail(classInput, "reading proof file", err)
+			}
+			proof = pf.Proof
+			if pf.Address != nil {
+				result.Address = pf.Address.Hex()
+				result.Amount = pf.Amount.String()
+			}
+
+			// A root argument wins, so an entry can be checked against the root deployed on chain
+			switch {
+			case len(args) == 1:
+				root, err = parseHash(args[0], "argument 1")
+				if err != nil {
+					return fail(classInput, "parsing root", err)
+				}
+			case pf.Root != nil:
+				root = *pf.Root
+			default:
+				return fail(classUsage, "", errors.New("proof file has no root, pass it as an argument"))
+			}
+
+			if index < 0 && pf.LeafIndex != nil {
+				index = *pf.LeafIndex
+				if pf.LeafCount != nil {
+					leafCount = *pf.LeafCount
+				}
+			}
+		} else {
+			// The root and proof elements are always bytes32 hex, only the target follows --leaf-format
+			root, err = parseHash(args[0], "argument 1")
 			if err != nil {
-				fmt.Printf("Error parsing proof: %v\n", err)
-				os.Exit(1)
+				return fail(classInput, "parsing root", err)
 			}
+
+			target, err = parser.parse(args[1], "argument 2")
+			if err != nil {
+				return fail(classInput, "parsing target", err)
+			}
+
+			proof, err = collectLeaves(cmd, args[2:], 3, parseHash)
+			if err != nil {
+				return fail(classInput, "parsing proof", err)
+			}
+		}
+
+		if index < 0 && pairs == merkle.PositionalPairs {
+			return fail(classUsage, "", errors.New("positional proofs need --index and --leaf-count"))
+		}
+		if index >= 0 && leafCount < 1 {
+			if !cmd.Flags().Changed("index") {
+				return fail(classUsage, "", errors.New("proof file has an index but no leaf count, pass --leaf-count"))
+			}
+			return fail(classUsage, "", errors.New("--index needs --leaf-count"))
+		}
+		if index >= leafCount && leafCount > 0 {
+			return fail(classUsage, "", fmt.Errorf("--index %d is out of range for %d leaves", index, leafCount))
 		}
 
-		isValid := merkle.VerifyProof(proof, root, target)
-		fmt.Printf("Proof is valid: %t\n", isValid)
+		var isValid bool
+		if index >= 0 {
+			isValid = merkle.VerifyProofAt(proof, root, target, index, leafCount, opts...)
+		} else {
+			isValid = merkle.VerifyProof(proof, root, target, opts...)
+		}
+
+		result.Valid = isValid
+		result.Root = root.Hex()
+		result.Target = target.Hex()
+		result.Proof = formatProof(proof)
+		text := func(w io.Writer) {
+			fmt.Fprintf(w, "Proof is valid: %t\n", isValid)
+		}
+		if !isValid {
+			return &cliError{
+				class:  classProofInvalid,
+				err:    errors.New("proof is not valid for the given root and target"),
+				result: result,
+				text:   text,
+			}
+		}
+		return writeResult(cmd, result, text)
 	},
 }
 
+// hashResult is the result of the hash command
+type hashResult struct {
+	Hash string `json:"hash"`
+}
+
 var hashDataCmd = &cobra.Command{
 	Use:   "hash [data]",
 	Short: "Hash arbitrary data to bytes32",
-	Long:  `Hash arbitrary string data to bytes32 using Keccak256.`,
+	Long:  `Hash arbitrary string data to bytes32 using Keccak256, or the function selected with --hash.`,
 	Args:  cobra.ExactArgs(1),
-	Run: func(cmd *cobra.Command, args []string) {
-		hash := merkle.HashData([]byte(args[0]))
-		fmt.Printf("Hash: %s\n", hash.Hex())
+	RunE: func(cmd *cobra.Command, args []string) error {
+		hashName, _ := cmd.Flags().GetString("hash")
+		hashFunc, err := merkle.HashFuncByName(hashName)
+		if err != nil {
+			return fail(classUsage, "", err)
+		}
+
+		hash := hashFunc([]byte(args[0]))
+		return writeResult(cmd, hashResult{Hash: hash.Hex()}, func(w io.Writer) {
+			fmt.Fprintf(w, "Hash: %s\n", hash.Hex())
+		})
 	},
 }
 
+// hashAddressAmountResult is the result of the hash-address-amount command
+type hashAddressAmountResult struct {
+	Address string `json:"address"`
+	Amount  string `json:"amount"`
+	Hash    string `json:"hash"`
+}
+
 var hashAddressAmountCmd = &cobra.Command{
 	Use:   "hash-address-amount [address] [amount]",
 	Short: "Hash address + amount to bytes32 (like TokenClaimer.sol)",
 	Long:  `Hash address + amount to bytes32 using keccak256(abi.encodePacked(address, amount)).`,
 	Args:  cobra.ExactArgs(2),
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		address := common.HexToAddress(args[0])
 		if address == (common.Address{}) {
-			fmt.Printf("Error parsing address: %s\n", args[0])
-			os.Exit(1)
+			return fail(classInput, "parsing address", fmt.Errorf("invalid address %s", args[0]))
 		}
 
-		amount, ok := new(big.Int).SetString(args[1], 10)
-		if !ok {
-			fmt.Printf("Error parsing amount: %s\n", args[1])
-			os.Exit(1)
+		decimalAmount, decimals, err := amountDecimals(cmd)
+		if err != nil {
+			return err
+		}
+		var amount *big.Int
+		if decimalAmount {
+			amount, err = util.ParseDecimalAmount(args[1], decimals)
+			if err != nil {
+				return fail(classInput, "parsing amount", err)
+			}
+		} else {
+			var ok bool
+			amount, ok = new(big.Int).SetString(args[1], 10)
+			if !ok {
+				return fail(classInput, "parsing amount", fmt.Errorf("invalid amount %s", args[1]))
+			}
 		}
 
 		hash := merkle.HashAddressAmount(address, amount)
-		fmt.Printf("Address: %s\n", address.Hex())
-		fmt.Printf("Amount: %s\n", amount.String())
-		fmt.Printf("Hash: %s\n", hash.Hex())
+		result := hashAddressAmountResult{
+			Address: address.Hex(),
+			Amount:  amount.String(),
+			Hash:    hash.Hex(),
+		}
+		return writeResult(cmd, result, func(w io.Writer) {
+			fmt.Fprintf(w, "Address: %s\n", address.Hex())
+			fmt.Fprintf(w, "Amount: %s\n", amount.String())
+			fmt.Fprintf(w, "Hash: %s\n", hash.Hex())
+		})
+	},
+}
+
+// hashLeafResult is the result of the hash-leaf command
+type hashLeafResult struct {
+	Leaf    string `json:"leaf"`
+	Encoded string `json:"encoded"`
+	Hash    string `json:"hash"`
+}
+
+var hashLeafCmd = &cobra.Command{
+	Use:   "hash-leaf [value1] [value2] ...",
+	Short: "Hash values of any Solidity types to a bytes32 leaf",
+	Long: `Hash values to a leaf following a Solidity type list, e.g. for Uniswap's MerkleDistributor:
+  hash-leaf --types uint256,address,uint256 0 0x742d35Cc6634C0532925a3b8D4C9db96C4b4d8b6 1000
+Use --encoding standard for abi.encode instead of abi.encodePacked, and --double-hash for
+keccak256(bytes.concat(keccak256(...))) leaves.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		types, _ := cmd.Flags().GetString("types")
+		encodingName, _ := cmd.Flags().GetString("encoding")
+		doubleHash, _ := cmd.Flags().GetBool("double-hash")
+
+		encoding, err := merkle.LeafEncodingByName(encodingName)
+		if err != nil {
+			return fail(classUsage, "", err)
+		}
+
+		encoder, err := merkle.NewLeafEncoder(merkle.ParseTypeList(types), encoding, doubleHash)
+		if err != nil {
+			return fail(classUsage, "parsing types", err)
+		}
+
+		values := make([]interface{}, len(args))
+		for i, arg := range args {
+			values[i] = arg
+		}
+
+		encoded, err := encoder.Encode(values...)
+		if err != nil {
+			return fail(classInput, "encoding values", err)
+		}
+		hash, _ := encoder.Hash(values...)
+
+		result := hashLeafResult{
+			Leaf:    encoder.String(),
+			Encoded: hexutil.Encode(encoded),
+			Hash:    hash.Hex(),
+		}
+		return writeResult(cmd, result, func(w io.Writer) {
+			fmt.Fprintf(w, "Leaf: %s\n", result.Leaf)
+			fmt.Fprintf(w, "Encoded: %s\n", result.Encoded)
+			fmt.Fprintf(w, "Hash: %s\n", result.Hash)
+		})
 	},
 }
 
-func parseLeaves(args []string) ([]common.Hash, error) {
-	leaves := make([]common.Hash, len(args))
-	for i, arg := range args {
-		// Remove any whitespace
-		arg = strings.TrimSpace(arg)
+// buildResult summarizes the artifact written by the build command
+type buildResult struct {
+	Root        string `json:"root"`
+	Leaf        string `json:"leaf"`
+	Entries     int    `json:"entries"`
+	TotalAmount string `json:"totalAmount"`
+	Artifact    string `json:"artifact"`
+}
+
+var buildCmd = &cobra.Command{
+	Use:   "build",
+	Short: "Build a distribution artifact with every recipient's proof from a CSV",
+	Long: `Build a Merkle tree from an address,amount CSV and write one JSON artifact
+holding the root, leaf encoding, total amount, entry count and every recipient's index, amount, leaf and proof.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		input, _ := cmd.Flags().GetString("input")
+		out, _ := cmd.Flags().GetString("out")
+
+		encoder, err := util.NewLeafEncoder(leafConfig(cmd))
+		if err != nil {
+			return fail(classUsage, "in leaf configuration", err)
+		}
+
+		csvOpts, err := csvOptions(cmd)
+		if err != nil {
+			return err
+		}
 
-		// If it doesn't start with 0x, assume it's raw data to be hashed
-		if !strings.HasPrefix(arg, "0x") {
-			leaves[i] = merkle.HashData([]byte(arg))
+		testCases, report, err := util.ReadAllocations(input, csvOpts)
+		if err != nil {
+			return fail(classInput, "reading CSV", err)
+		}
+		for _, invalid := range report.Invalid {
+			fmt.Fprintf(os.Stderr, "Warning: skipping %s\n", invalid)
+		}
+		report.Duplicates.Write(os.Stderr)
+
+		distribution, err := util.BuildDistribution(testCases, encoder, treeConfig(cmd))
+		if err != nil {
+			return fail(classTree, "building distribution", err)
+		}
+
+		// Keep stdout clean for the artifact when writing it there
+		summary := os.Stdout
+		if out == "-" {
+			summary = os.Stderr
+			err = distribution.WriteArtifact(os.Stdout)
 		} else {
-			hash, err := merkle.HexToHash(arg)
+			err = distribution.WriteArtifactFile(out)
+		}
+		if err != nil {
+			return fail(classOutput, "writing artifact", err)
+		}
+
+		result := buildResult{
+			Root:        distribution.MerkleData.Root.Hex(),
+			Leaf:        encoder.String(),
+			Entries:     len(testCases),
+			TotalAmount: distribution.TotalAmount.String(),
+			Artifact:    out,
+		}
+		return writeResultTo(summary, cmd, result, func(w io.Writer) {
+			fmt.Fprintf(w, "Merkle Root: %s\n", result.Root)
+			fmt.Fprintf(w, "Leaf: %s\n", result.Leaf)
+			fmt.Fprintf(w, "Entries: %d\n", result.Entries)
+			fmt.Fprintf(w, "Total Amount: %s\n", result.TotalAmount)
+			if out != "-" {
+				fmt.Fprintf(w, "Artifact written to: %s\n", out)
+			}
+		})
+	},
+}
+
+// standardProofResult is the result of standard-tree with --index
+type standardProofResult struct {
+	Value []interface{} `json:"value"`
+	Leaf  string        `json:"leaf"`
+	Root  string        `json:"root"`
+	Proof []string      `json:"proof"`
+}
+
+var standardTreeCmd = &cobra.Command{
+	Use:   "standard-tree [values.json]",
+	Short: "Build an OpenZeppelin StandardMerkleTree from a JSON array of values",
+	Long: `Build a tree compatible with @openzeppelin/merkle-tree's StandardMerkleTree.
+The input is a JSON array of values, e.g. [["0x1111...", "5000000000000000000"], ...].
+Prints the same JSON as StandardMerkleTree.dump(), or the proof for one value with --index.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		encoding, _ := cmd.Flags().GetStringSlice("encoding")
+		index, _ := cmd.Flags().GetInt("index")
+
+		values, err := readStandardValues(args[0])
+		if err != nil {
+			return fail(classInput, "reading values", err)
+		}
+
+		tree, err := merkle.NewStandardMerkleTree(values, encoding)
+		if err != nil {
+			return fail(classTree, "creating standard Merkle tree", err)
+		}
+
+		var result interface{} = tree.Dump()
+		if index >= 0 {
+			proof, err := tree.GenerateProofAt(index)
 			if err != nil {
-				return nil, fmt.Errorf("invalid hex at position %d: %v", i, err)
+				return fail(classTree, "generating proof", err)
+			}
+			leaf, _ := tree.LeafHash(values[index])
+			result = standardProofResult{
+				Value: values[index],
+				Leaf:  leaf.Hex(),
+				Root:  tree.GenerateRoot().Hex(),
+				Proof: formatProof(proof),
 			}
-			leaves[i] = hash
 		}
+
+		return writeResult(cmd, result, func(w io.Writer) {
+			writeJSON(w, result)
+		})
+	},
+}
+
+// multiProofResult is the result of the multiproof command
+type multiProofResult struct {
+	Root       string          `json:"root"`
+	Leaves     []string        `json:"leaves"`
+	Values     [][]interface{} `json:"values"`
+	Proof      []string        `json:"proof"`
+	ProofFlags []bool          `json:"proofFlags"`
+}
+
+var multiProofCmd = &cobra.Command{
+	Use:   "multiproof [values.json]",
+	Short: "Generate a multiproof for several values of a StandardMerkleTree",
+	Long: `Generate a proof for several values at once, compatible with OpenZeppelin's MerkleProof.multiProofVerify.
+The tree is built from a JSON array of values exactly like the standard-tree command.
+Pass the leaves to the contract in the order they are printed.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		encoding, _ := cmd.Flags().GetStringSlice("encoding")
+		indices, _ := cmd.Flags().GetIntSlice("indices")
+
+		values, err := readStandardValues(args[0])
+		if err != nil {
+			return fail(classInput, "reading values", err)
+		}
+
+		tree, err := merkle.NewStandardMerkleTree(values, encoding)
+		if err != nil {
+			return fail(classTree, "creating standard Merkle tree", err)
+		}
+
+		multiProof, err := tree.GenerateMultiProofAt(indices)
+		if err != nil {
+			return fail(classTree, "generating multiproof", err)
+		}
+
+		result := multiProofResult{
+			Root:       tree.GenerateRoot().Hex(),
+			Leaves:     formatProof(multiProof.Leaves),
+			Values:     multiProof.Values,
+			Proof:      formatProof(multiProof.Proof),
+			ProofFlags: multiProof.ProofFlags,
+		}
+		return writeResult(cmd, result, func(w io.Writer) {
+			writeJSON(w, result)
+		})
+	},
+}
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve the root and per-recipient proofs of a distribution over HTTP",
+	Long: `Load an allocation CSV, or an artifact written by build (.json), build the tree once and serve:
+  GET  /root               root, tree and leaf configuration, entry count and total amount
+  GET  /proof/{address}    the recipient's index, amount, leaf and proof
+  POST /verify             {"address", "amount", "proof"} or {"leaf", "proof"}, with an optional "root"
+  GET  /metrics            Prometheus metrics
+The input is reloaded when it changes; if the new version is invalid the old tree keeps being served.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		input, _ := cmd.Flags().GetString("input")
+		listen, _ := cmd.Flags().GetString("listen")
+		reloadInterval, _ := cmd.Flags().GetDuration("reload-interval")
+		corsOrigin, _ := cmd.Flags().GetString("cors-origin")
+		csvOpts, err := csvOptions(cmd)
+		if err != nil {
+			return err
+		}
+
+		server, err := util.NewProofServer(util.ProofServerConfig{
+			Path:           input,
+			Leaf:           leafConfig(cmd),
+			Tree:           treeConfig(cmd),
+			CSV:            csvOpts,
+			ReloadInterval: reloadInterval,
+			CORSOrigin:     corsOrigin,
+		})
+		if err != nil {
+			return fail(classInput, "loading distribution", err)
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		go server.Watch(ctx)
+
+		httpServer := &http.Server{
+			Addr:              listen,
+			Handler:           server.Handler(),
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+		go func() {
+			<-ctx.Done()
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			httpServer.Shutdown(shutdownCtx)
+		}()
+
+		log.Printf("Serving root %s from %s on %s", server.Root().Hex(), input, listen)
+		if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			return fail(classServer, "serving", err)
+		}
+		return nil
+	},
+}
+
+// readStandardValues reads a JSON array of values, keeping numbers exact
+func readStandardValues(filePath string) ([][]interface{}, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	decoder := json.NewDecoder(file)
+	decoder.UseNumber()
+
+	var values [][]interface{}
+	if err := decoder.Decode(&values); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	return values, nil
+}
+
+// addTreeFlags registers the flags that select how tree nodes are hashed
+func addTreeFlags(cmd *cobra.Command) {
+	cmd.Flags().String("hash", "keccak256", "Hash function: keccak256, sha256, sha3-256 or blake2b")
+	cmd.Flags().String("pairs", merkle.SortedPairs.String(), "Pair ordering: sorted or positional")
+}
+
+// addLeafFlags registers the flags that describe how a CSV row becomes a leaf
+func addLeafFlags(cmd *cobra.Command) {
+	cmd.Flags().String("leaf-types", "", "Solidity types of each leaf value (default address,uint256)")
+	cmd.Flags().String("leaf-fields", "", "Source of each leaf value: index, address, amount or a CSV header name")
+	cmd.Flags().String("leaf-encoding", merkle.PackedEncoding.String(), "Leaf encoding: packed (abi.encodePacked) or standard (abi.encode)")
+	cmd.Flags().Bool("double-hash", false, "Hash the encoded leaf twice")
+}
+
+// leafConfig reads the leaf flags
+func leafConfig(cmd *cobra.Command) util.LeafConfig {
+	leafTypes, _ := cmd.Flags().GetString("leaf-types")
+	leafFields, _ := cmd.Flags().GetString("leaf-fields")
+	leafEncoding, _ := cmd.Flags().GetString("leaf-encoding")
+	doubleHash, _ := cmd.Flags().GetBool("double-hash")
+
+	return util.LeafConfig{
+		Types:      leafTypes,
+		Fields:     leafFields,
+		Encoding:   leafEncoding,
+		DoubleHash: doubleHash,
+	}
+}
+
+// addCSVFlags registers the flags that select and validate allocation CSV columns
+func addCSVFlags(cmd *cobra.Command) {
+	cmd.Flags().String("column", "", "Header names of the columns, e.g. address=wallet,amount=qty")
+	cmd.Flags().Bool("skip-invalid", false, "Skip and report invalid rows instead of failing")
+	cmd.Flags().String("duplicates", util.DuplicateError.String(), "What to do with an address on several rows: error, sum, first or last")
+}
+
+// csvOptions reads the CSV and decimals flags, failing with a classified error
+func csvOptions(cmd *cobra.Command) (util.CSVOptions, error) {
+	column, _ := cmd.Flags().GetString("column")
+	skipInvalid, _ := cmd.Flags().GetBool("skip-invalid")
+	duplicates, _ := cmd.Flags().GetString("duplicates")
+
+	columns, err := util.ParseColumnMapping(column)
+	if err != nil {
+		return util.CSVOptions{}, fail(classUsage, "in --column", err)
+	}
+	policy, err := util.DuplicatePolicyByName(duplicates)
+	if err != nil {
+		return util.CSVOptions{}, fail(classUsage, "in --duplicates", err)
+	}
+	decimalAmounts, decimals, err := amountDecimals(cmd)
+	if err != nil {
+		return util.CSVOptions{}, err
 	}
-	return leaves, nil
+	return util.CSVOptions{
+		Columns:        columns,
+		SkipInvalid:    skipInvalid,
+		Duplicates:     policy,
+		DecimalAmounts: decimalAmounts,
+		Decimals:       decimals,
+	}, nil
+}
+
+// addDecimalsFlags registers the flags for amounts written in whole tokens
+func addDecimalsFlags(cmd *cobra.Command) {
+	cmd.Flags().Int("decimals", -1, "Read amounts like 1,234.5 or 1.5e3 in whole tokens with this many decimals")
+	cmd.Flags().String("decimals-token", "", "ERC-20 token to read the decimals from, instead of --decimals")
+	cmd.Flags().String("rpc", "", "RPC endpoint used with --decimals-token")
+	cmd.MarkFlagsMutuallyExclusive("decimals", "decimals-token")
+}
+
+// amountDecimals reads the decimals flags; decimal is false when amounts are in base units
+func amountDecimals(cmd *cobra.Command) (decimal bool, decimals int, err error) {
+	decimals, _ = cmd.Flags().GetInt("decimals")
+	token, _ := cmd.Flags().GetString("decimals-token")
+	rpcURL, _ := cmd.Flags().GetString("rpc")
+
+	if token == "" {
+		if !cmd.Flags().Changed("decimals") {
+			return false, 0, nil
+		}
+		if decimals < 0 || decimals > util.MaxTokenDecimals {
+			return false, 0, fail(classUsage, "in --decimals", fmt.Errorf("must be between 0 and %d, got %d", util.MaxTokenDecimals, decimals))
+		}
+		return true, decimals, nil
+	}
+
+	if !common.IsHexAddress(token) {
+		return false, 0, fail(classUsage, "in --decimals-token", fmt.Errorf("invalid address %s", token))
+	}
+	if rpcURL == "" {
+		return false, 0, fail(classUsage, "in --decimals-token", fmt.Errorf("--rpc is required to read the token's decimals"))
+	}
+	client, err := util.NewEthClient(rpcURL)
+	if err != nil {
+		return false, 0, fail(classInput, "reading token decimals", err)
+	}
+	defer client.Close()
+
+	decimals, err = client.TokenDecimals(common.HexToAddress(token))
+	if err != nil {
+		return false, 0, fail(classInput, "reading token decimals", err)
+	}
+	return true, decimals, nil
+}
+
+// treeConfig reads the tree flags by name, for code that takes a util.TreeConfig
+func treeConfig(cmd *cobra.Command) util.TreeConfig {
+	hashName, _ := cmd.Flags().GetString("hash")
+	pairs, _ := cmd.Flags().GetString("pairs")
+	return util.TreeConfig{Hash: hashName, Pairs: pairs}
+}
+
+// pairStrategy parses --pairs, which accepts any case
+func pairStrategy(cmd *cobra.Command) (merkle.PairStrategy, error) {
+	pairsName, _ := cmd.Flags().GetString("pairs")
+	return merkle.PairStrategyByName(pairsName)
+}
+
+// treeOptions turns the tree flags into merkle options, also returning the hash function for raw leaves
+func treeOptions(cmd *cobra.Command) (merkle.HashFunc, []merkle.Option, error) {
+	hashName, _ := cmd.Flags().GetString("hash")
+
+	hashFunc, err := merkle.HashFuncByName(hashName)
+	if err != nil {
+		return nil, nil, err
+	}
+	pairs, err := pairStrategy(cmd)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return hashFunc, []merkle.Option{merkle.WithHashFunc(hashFunc), merkle.WithPairStrategy(pairs)}, nil
 }
 
 func formatProof(proof []common.Hash) []string {
@@ -188,16 +821,70 @@ func formatProof(proof []common.Hash) []string {
 }
 
 func init() {
+	rootCmd.PersistentFlags().StringVar(&outputFormat, "output", outputText, "Output format: text or json")
+
 	rootCmd.AddCommand(generateRootCmd)
 	rootCmd.AddCommand(generateProofCmd)
 	rootCmd.AddCommand(verifyProofCmd)
 	rootCmd.AddCommand(hashDataCmd)
 	rootCmd.AddCommand(hashAddressAmountCmd)
+	rootCmd.AddCommand(hashLeafCmd)
+	rootCmd.AddCommand(buildCmd)
+	rootCmd.AddCommand(standardTreeCmd)
+	rootCmd.AddCommand(multiProofCmd)
+	rootCmd.AddCommand(serveCmd)
+
+	addTreeFlags(generateRootCmd)
+	addTreeFlags(generateProofCmd)
+	addTreeFlags(verifyProofCmd)
+	addLeavesFileFlag(generateRootCmd, "File with one leaf per line or a JSON array of leaves, or - for stdin")
+	addLeavesFileFlag(generateProofCmd, "File with one leaf per line or a JSON array of leaves, or - for stdin")
+	addLeavesFileFlag(verifyProofCmd, "File with one proof element per line or a JSON array, or - for stdin")
+	addLeafFormatFlags(generateRootCmd)
+	addLeafFormatFlags(generateProofCmd)
+	addLeafFormatFlags(verifyProofCmd)
+	generateProofCmd.Flags().Bool("positional", false, "Also print the leaf index and left/right direction bits")
+	verifyProofCmd.Flags().Int("index", -1, "Position of the target leaf, required for positional trees")
+	verifyProofCmd.Flags().Int("leaf-count", 0, "Number of leaves in the tree, used with --index")
+	verifyProofCmd.Flags().String("proof-file", "", "JSON from the proof command or a per-recipient entry with address, amount and proof, or - for stdin")
+	verifyProofCmd.MarkFlagsMutuallyExclusive("proof-file", "leaves-file")
+	hashDataCmd.Flags().String("hash", "keccak256", "Hash function: keccak256, sha256, sha3-256 or blake2b")
+	addDecimalsFlags(hashAddressAmountCmd)
+
+	buildCmd.Flags().String("input", "", "Allocation CSV with address,amount columns and an optional header row")
+	buildCmd.Flags().String("out", "", "Artifact file to write, or - for stdout")
+	addCSVFlags(buildCmd)
+	addDecimalsFlags(buildCmd)
+	addLeafFlags(buildCmd)
+	addTreeFlags(buildCmd)
+	buildCmd.MarkFlagRequired("input")
+	buildCmd.MarkFlagRequired("out")
+
+	hashLeafCmd.Flags().String("types", "address,uint256", "Solidity types of the values, e.g. uint256,address,uint256")
+	hashLeafCmd.Flags().String("encoding", merkle.PackedEncoding.String(), "Leaf encoding: packed (abi.encodePacked) or standard (abi.encode)")
+	hashLeafCmd.Flags().Bool("double-hash", false, "Hash the encoded values twice")
+
+	standardTreeCmd.Flags().StringSlice("encoding", []string{"address", "uint256"}, "Solidity types of each value, e.g. address,uint256")
+	standardTreeCmd.Flags().Int("index", -1, "Print the proof for the value at this index instead of the tree dump")
+
+	multiProofCmd.Flags().StringSlice("encoding", []string{"address", "uint256"}, "Solidity types of each value, e.g. address,uint256")
+	multiProofCmd.Flags().IntSlice("indices", nil, "Indices of the values to prove, e.g. 0,2,5")
+	multiProofCmd.MarkFlagRequired("indices")
+
+	serveCmd.Flags().String("input", "", "Allocation CSV, or a distribution artifact ending in .json")
+	serveCmd.Flags().String("listen", ":8080", "Address to listen on")
+	serveCmd.Flags().Duration("reload-interval", 2*time.Second, "How often to check the input for changes, 0 to disable reloading")
+	serveCmd.Flags().String("cors-origin", "", "Value for Access-Control-Allow-Origin, e.g. * or https://claim.example.org")
+	addCSVFlags(serveCmd)
+	addDecimalsFlags(serveCmd)
+	addLeafFlags(serveCmd)
+	addTreeFlags(serveCmd)
+	serveCmd.MarkFlagRequired("input")
 }
 
 func main() {
-	if err := rootCmd.Execute(); err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+	outputFormat = outputFormatFromArgs(os.Args[1:])
+	if cmd, err := rootCmd.ExecuteC(); err != nil {
+		os.Exit(reportError(cmd, err))
 	}
 }
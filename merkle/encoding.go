@@ -0,0 +1,226 @@
+package merkle
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// parseArguments turns a list of Solidity type names (e.g. "address", "uint256") into ABI arguments
+func parseArguments(types []string) (abi.Arguments, error) {
+	if len(types) == 0 {
+		return nil, fmt.Errorf("leaf encoding cannot be empty")
+	}
+
+	arguments := make(abi.Arguments, len(types))
+	for i, typeName := range types {
+		typ, err := abi.NewType(strings.TrimSpace(typeName), "", nil)
+		if err != nil {
+			return nil, fmt.Errorf("invalid type %q at position %d: %w", typeName, i, err)
+		}
+		if err := validateType(typ); err != nil {
+			return nil, fmt.Errorf("unsupported type %q at position %d: %w", typeName, i, err)
+		}
+		arguments[i] = abi.Argument{Type: typ}
+	}
+
+	return arguments, nil
+}
+
+// validateType rejects types that abi.NewType accepts but Solidity does not, or that cannot be leaf values
+func validateType(typ abi.Type) error {
+	switch typ.T {
+	case abi.UintTy, abi.IntTy:
+		if typ.Size < 8 || typ.Size > 256 || typ.Size%8 != 0 {
+			return fmt.Errorf("invalid integer size %d", typ.Size)
+		}
+	case abi.FixedBytesTy:
+		if typ.Size < 1 || typ.Size > 32 {
+			return fmt.Errorf("invalid fixed bytes size %d", typ.Size)
+		}
+	case abi.SliceTy, abi.ArrayTy:
+		return validateType(*typ.Elem)
+	case abi.TupleTy, abi.FunctionTy, abi.FixedPointTy:
+		return fmt.Errorf("tuple, function and fixed point types are not supported")
+	}
+	return nil
+}
+
+// convertValues converts loosely typed values (strings, JSON numbers, Go values) into
+// the concrete Go types expected by the ABI packer for each argument
+func convertValues(arguments abi.Arguments, values []interface{}) ([]interface{}, error) {
+	if len(values) != len(arguments) {
+		return nil, fmt.Errorf("expected %d values, got %d", len(arguments), len(values))
+	}
+
+	converted := make([]interface{}, len(values))
+	for i, value := range values {
+		v, err := convertValue(arguments[i].Type, value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s value at position %d: %w", arguments[i].Type.String(), i, err)
+		}
+		converted[i] = v
+	}
+
+	return converted, nil
+}
+
+// convertValue converts a single value to the Go representation of the given ABI type
+func convertValue(typ abi.Type, value interface{}) (interface{}, error) {
+	switch typ.T {
+	case abi.AddressTy:
+		switch v := value.(type) {
+		case common.Address:
+			return v, nil
+		case string:
+			if !common.IsHexAddress(v) {
+				return nil, fmt.Errorf("invalid address: %s", v)
+			}
+			return common.HexToAddress(v), nil
+		}
+
+	case abi.BoolTy:
+		switch v := value.(type) {
+		case bool:
+			return v, nil
+		case string:
+			switch v {
+			case "true":
+				return true, nil
+			case "false":
+				return false, nil
+			}
+			return nil, fmt.Errorf("invalid bool: %s", v)
+		}
+
+	case abi.StringTy:
+		if v, ok := value.(string); ok {
+			return v, nil
+		}
+
+	case abi.BytesTy:
+		switch v := value.(type) {
+		case []byte:
+			return v, nil
+		case string:
+			b, err := hexutil.Decode(v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid bytes %q: %w", v, err)
+			}
+			return b, nil
+		}
+
+	case abi.FixedBytesTy:
+		var b []byte
+		switch v := value.(type) {
+		case common.Hash:
+			b = v.Bytes()
+		case []byte:
+			b = v
+		case string:
+			decoded, err := hexutil.Decode(v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid bytes%d %q: %w", typ.Size, v, err)
+			}
+			b = decoded
+		default:
+			return nil, fmt.Errorf("unsupported value type %T", value)
+		}
+		if len(b) != typ.Size {
+			return nil, fmt.Errorf("expected %d bytes, got %d", typ.Size, len(b))
+		}
+		array := reflect.New(typ.GetType()).Elem()
+		reflect.Copy(array, reflect.ValueOf(b))
+		return array.Interface(), nil
+
+	case abi.UintTy, abi.IntTy:
+		n, err := toBigInt(value)
+		if err != nil {
+			return nil, err
+		}
+		if typ.T == abi.UintTy {
+			if n.Sign() < 0 || n.BitLen() > typ.Size {
+				return nil, fmt.Errorf("%s out of range for uint%d", n.String(), typ.Size)
+			}
+		} else {
+			limit := new(big.Int).Lsh(big.NewInt(1), uint(typ.Size-1))
+			if n.Cmp(limit) >= 0 || n.Cmp(new(big.Int).Neg(limit)) < 0 {
+				return nil, fmt.Errorf("%s out of range for int%d", n.String(), typ.Size)
+			}
+		}
+		goType := typ.GetType()
+		if goType == reflect.TypeOf(&big.Int{}) {
+			return n, nil
+		}
+		if typ.T == abi.UintTy {
+			return reflect.ValueOf(n.Uint64()).Convert(goType).Interface(), nil
+		}
+		return reflect.ValueOf(n.Int64()).Convert(goType).Interface(), nil
+
+	case abi.SliceTy, abi.ArrayTy:
+		items, ok := value.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("expected a list, got %T", value)
+		}
+		if typ.T == abi.ArrayTy && len(items) != typ.Size {
+			return nil, fmt.Errorf("expected %d elements, got %d", typ.Size, len(items))
+		}
+
+		var list reflect.Value
+		if typ.T == abi.SliceTy {
+			list = reflect.MakeSlice(typ.GetType(), len(items), len(items))
+		} else {
+			list = reflect.New(typ.GetType()).Elem()
+		}
+		for i, item := range items {
+			v, err := convertValue(*typ.Elem, item)
+			if err != nil {
+				return nil, fmt.Errorf("element %d: %w", i, err)
+			}
+			list.Index(i).Set(reflect.ValueOf(v))
+		}
+		return list.Interface(), nil
+	}
+
+	return nil, fmt.Errorf("unsupported value type %T", value)
+}
+
+// toBigInt converts decimal/hex strings, JSON numbers and Go integers to a big.Int
+func toBigInt(value interface{}) (*big.Int, error) {
+	switch v := value.(type) {
+	case *big.Int:
+		return new(big.Int).Set(v), nil
+	case json.Number:
+		return toBigInt(string(v))
+	case string:
+		base, digits := 10, v
+		if strings.HasPrefix(v, "0x") || strings.HasPrefix(v, "0X") {
+			base, digits = 16, v[2:]
+		}
+		n, ok := new(big.Int).SetString(digits, base)
+		if !ok {
+			return nil, fmt.Errorf("invalid integer: %s", v)
+		}
+		return n, nil
+	case int:
+		return big.NewInt(int64(v)), nil
+	case int64:
+		return big.NewInt(v), nil
+	case uint64:
+		return new(big.Int).SetUint64(v), nil
+	case float64:
+		// JSON numbers decoded without UseNumber; only accept exactly representable integers
+		if v != math.Trunc(v) || math.Abs(v) > 1<<53 {
+			return nil, fmt.Errorf("number %v cannot be represented exactly, pass it as a string", v)
+		}
+		return big.NewInt(int64(v)), nil
+	}
+	return nil, fmt.Errorf("unsupported value type %T", value)
+}
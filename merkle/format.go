@@ -0,0 +1,132 @@
+package merkle
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// LeafFormat selects how a textual input is turned into a leaf
+type LeafFormat int
+
+const (
+	// AutoLeafFormat treats 0x-prefixed bytes32 values as hex and anything not starting with 0x as raw data.
+	// Inputs that start with 0x but are not 32 bytes of hex are rejected rather than guessed at.
+	AutoLeafFormat LeafFormat = iota
+	// RawLeafFormat hashes the input bytes as they are
+	RawLeafFormat
+	// HexLeafFormat decodes a 0x-prefixed bytes32 value
+	HexLeafFormat
+	// AddressLeafFormat left-pads a 0x-prefixed 20-byte address to bytes32
+	AddressLeafFormat
+)
+
+// String returns the name accepted by LeafFormatByName
+func (f LeafFormat) String() string {
+	switch f {
+	case AutoLeafFormat:
+		return "auto"
+	case RawLeafFormat:
+		return "raw"
+	case HexLeafFormat:
+		return "hex"
+	case AddressLeafFormat:
+		return "address"
+	}
+	return fmt.Sprintf("LeafFormat(%d)", int(f))
+}
+
+// LeafFormatByName returns the leaf format for "auto", "raw", "hex" or "address"
+func LeafFormatByName(name string) (LeafFormat, error) {
+	switch strings.ToLower(name) {
+	case "auto", "":
+		return AutoLeafFormat, nil
+	case "raw":
+		return RawLeafFormat, nil
+	case "hex":
+		return HexLeafFormat, nil
+	case "address":
+		return AddressLeafFormat, nil
+	}
+	return 0, fmt.Errorf("unknown leaf format %q (expected auto, raw, hex or address)", name)
+}
+
+// DetectLeafFormat returns the format AutoLeafFormat would parse the input as
+// Inputs with a 0x prefix must be a bytes32 or an address; anything else with the prefix is an error.
+func DetectLeafFormat(input string) (LeafFormat, error) {
+	if !has0xPrefix(input) {
+		return RawLeafFormat, nil
+	}
+
+	switch digits := len(input) - 2; digits {
+	case 2 * common.HashLength:
+		return HexLeafFormat, nil
+	case 2 * common.AddressLength:
+		return AddressLeafFormat, nil
+	default:
+		return 0, fmt.Errorf("%q starts with 0x but has %d hex digits, expected 64 for a bytes32 or 40 for an address", input, digits)
+	}
+}
+
+// ParseLeaf turns an input into a leaf using the given format, returning the format that was applied
+// Raw inputs are hashed with hashFunc. With AutoLeafFormat, addresses must be selected explicitly
+// with AddressLeafFormat so that they are never padded by accident.
+func ParseLeaf(input string, format LeafFormat, hashFunc HashFunc) (common.Hash, LeafFormat, error) {
+	if format == AutoLeafFormat {
+		detected, err := DetectLeafFormat(input)
+		if err != nil {
+			return common.Hash{}, format, err
+		}
+		if detected == AddressLeafFormat {
+			return common.Hash{}, detected, fmt.Errorf("%q is an address, use the address leaf format to pad it to bytes32", input)
+		}
+		format = detected
+	}
+
+	switch format {
+	case RawLeafFormat:
+		return hashFunc([]byte(input)), format, nil
+	case HexLeafFormat:
+		leaf, err := HexToHash(input)
+		return leaf, format, err
+	case AddressLeafFormat:
+		leaf, err := AddressToHash(input)
+		return leaf, format, err
+	}
+	return common.Hash{}, format, fmt.Errorf("unknown leaf format %d", int(format))
+}
+
+// AddressToHash left-pads a 0x-prefixed address to a bytes32 leaf
+// Mixed-case addresses must carry a valid EIP-55 checksum.
+func AddressToHash(input string) (common.Hash, error) {
+	if !has0xPrefix(input) || !common.IsHexAddress(input) {
+		return common.Hash{}, fmt.Errorf("invalid address %q: expected 0x followed by 40 hex digits", input)
+	}
+
+	address := common.HexToAddress(input)
+	digits := input[2:]
+	if digits != strings.ToLower(digits) && digits != strings.ToUpper(digits) && digits != address.Hex()[2:] {
+		return common.Hash{}, fmt.Errorf("invalid address %q: bad checksum, expected %s", input, address.Hex())
+	}
+
+	return common.BytesToHash(address.Bytes()), nil
+}
+
+// has0xPrefix reports whether the input starts with 0x or 0X
+func has0xPrefix(input string) bool {
+	return len(input) >= 2 && input[0] == '0' && (input[1] == 'x' || input[1] == 'X')
+}
+
+// decodeHash decodes exactly 64 hex digits into a hash
+func decodeHash(digits string) (common.Hash, error) {
+	if len(digits) != 2*common.HashLength {
+		return common.Hash{}, fmt.Errorf("expected 64 hex digits, got %d", len(digits))
+	}
+	b, err := hex.DecodeString(digits)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("invalid hex digit: %v", err)
+	}
+	return common.BytesToHash(b), nil
+}
@@ -0,0 +1,101 @@
+package merkle
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestDetectLeafFormat(t *testing.T) {
+	cases := map[string]LeafFormat{
+		"alice": RawLeafFormat,
+		"0x1234567890abcdef1234567890abcdef1234567890abcdef1234567890abcdef": HexLeafFormat,
+		"0X1234567890abcdef1234567890abcdef1234567890abcdef1234567890abcdef": HexLeafFormat,
+		"0x5B38Da6a701c568545dCfcB03FcB875f56beddC4":                         AddressLeafFormat,
+	}
+	for input, expected := range cases {
+		format, err := DetectLeafFormat(input)
+		if err != nil {
+			t.Errorf("Unexpected error for %s: %v", input, err)
+		}
+		if format != expected {
+			t.Errorf("Expected %s for %s, got %s", expected, input, format)
+		}
+	}
+
+	// A 0x prefix with the wrong length is a typo, not raw data
+	if _, err := DetectLeafFormat("0x1234"); err == nil {
+		t.Error("Expected error for short hex")
+	}
+}
+
+func TestParseLeaf(t *testing.T) {
+	hexLeaf := "0x1234567890abcdef1234567890abcdef1234567890abcdef1234567890abcdef"
+	address := "0x5B38Da6a701c568545dCfcB03FcB875f56beddC4"
+
+	leaf, format, err := ParseLeaf("alice", AutoLeafFormat, Keccak256)
+	if err != nil || format != RawLeafFormat || leaf != HashData([]byte("alice")) {
+		t.Errorf("Auto format should hash raw data, got %s %s %v", leaf.Hex(), format, err)
+	}
+
+	leaf, format, err = ParseLeaf(hexLeaf, AutoLeafFormat, Keccak256)
+	if err != nil || format != HexLeafFormat || leaf != common.HexToHash(hexLeaf) {
+		t.Errorf("Auto format should decode hex, got %s %s %v", leaf.Hex(), format, err)
+	}
+
+	// Raw format hashes hex-looking input instead of decoding it
+	leaf, _, err = ParseLeaf(hexLeaf, RawLeafFormat, Keccak256)
+	if err != nil || leaf != HashData([]byte(hexLeaf)) {
+		t.Error("Raw format should hash the input bytes")
+	}
+
+	// Addresses are only padded when asked for
+	if _, _, err := ParseLeaf(address, AutoLeafFormat, Keccak256); err == nil {
+		t.Error("Expected auto format to reject an address")
+	}
+	if _, _, err := ParseLeaf(address, HexLeafFormat, Keccak256); err == nil {
+		t.Error("Expected hex format to reject an address")
+	}
+	leaf, _, err = ParseLeaf(address, AddressLeafFormat, Keccak256)
+	if err != nil || leaf != common.BytesToHash(common.HexToAddress(address).Bytes()) {
+		t.Errorf("Address format should pad the address, got %s %v", leaf.Hex(), err)
+	}
+
+	if _, _, err := ParseLeaf("alice", HexLeafFormat, Keccak256); err == nil {
+		t.Error("Expected hex format to reject raw data")
+	}
+}
+
+func TestAddressToHash(t *testing.T) {
+	for _, valid := range []string{
+		"0x5B38Da6a701c568545dCfcB03FcB875f56beddC4",
+		"0x5b38da6a701c568545dcfcb03fcb875f56beddc4",
+		"0x5B38DA6A701C568545DCFCB03FCB875F56BEDDC4",
+	} {
+		if _, err := AddressToHash(valid); err != nil {
+			t.Errorf("Unexpected error for %s: %v", valid, err)
+		}
+	}
+
+	for _, invalid := range []string{
+		"0x5B38Da6a701c568545dCfcB03FcB875f56beddc4", // bad checksum
+		"5B38Da6a701c568545dCfcB03FcB875f56beddC4",   // missing prefix
+		"0x5B38Da6a701c568545dCfcB03FcB875f56beddC",  // too short
+	} {
+		if _, err := AddressToHash(invalid); err == nil {
+			t.Errorf("Expected error for %s", invalid)
+		}
+	}
+}
+
+func TestLeafFormatByName(t *testing.T) {
+	for _, format := range []LeafFormat{AutoLeafFormat, RawLeafFormat, HexLeafFormat, AddressLeafFormat} {
+		parsed, err := LeafFormatByName(format.String())
+		if err != nil || parsed != format {
+			t.Errorf("Round trip failed for %s", format)
+		}
+	}
+	if _, err := LeafFormatByName("base64"); err == nil {
+		t.Error("Expected error for unknown leaf format")
+	}
+}
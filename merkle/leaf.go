@@ -0,0 +1,245 @@
+package merkle
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// LeafEncoding selects how leaf values are serialized before hashing
+type LeafEncoding int
+
+const (
+	// PackedEncoding is abi.encodePacked, as used by TokenClaimer.sol
+	PackedEncoding LeafEncoding = iota
+	// StandardEncoding is abi.encode, as used by OpenZeppelin's StandardMerkleTree
+	StandardEncoding
+)
+
+// String returns the name accepted by LeafEncodingByName
+func (e LeafEncoding) String() string {
+	switch e {
+	case PackedEncoding:
+		return "packed"
+	case StandardEncoding:
+		return "standard"
+	}
+	return fmt.Sprintf("LeafEncoding(%d)", int(e))
+}
+
+// LeafEncodingByName returns the encoding for "packed" (abi.encodePacked) or "standard" (abi.encode)
+func LeafEncodingByName(name string) (LeafEncoding, error) {
+	switch strings.ToLower(name) {
+	case "packed", "encodepacked":
+		return PackedEncoding, nil
+	case "standard", "encode":
+		return StandardEncoding, nil
+	}
+	return 0, fmt.Errorf("unknown leaf encoding %q (expected packed or standard)", name)
+}
+
+// ParseTypeList splits a Solidity type list such as "uint256,address,uint256"
+func ParseTypeList(list string) []string {
+	var types []string
+	for _, typeName := range strings.Split(list, ",") {
+		if typeName = strings.TrimSpace(typeName); typeName != "" {
+			types = append(types, typeName)
+		}
+	}
+	return types
+}
+
+// LeafEncoder hashes structured values into leaves following a Solidity type list, e.g.
+// keccak256(abi.encodePacked(uint256 index, address account, uint256 amount))
+type LeafEncoder struct {
+	types      []string
+	arguments  abi.Arguments
+	encoding   LeafEncoding
+	doubleHash bool
+}
+
+// NewLeafEncoder creates an encoder for the given Solidity types
+// With doubleHash the leaf is keccak256(bytes.concat(keccak256(encoded))), which prevents second preimage attacks
+func NewLeafEncoder(types []string, encoding LeafEncoding, doubleHash bool) (*LeafEncoder, error) {
+	if encoding != PackedEncoding && encoding != StandardEncoding {
+		return nil, fmt.Errorf("unknown leaf encoding %d", int(encoding))
+	}
+
+	arguments, err := parseArguments(types)
+	if err != nil {
+		return nil, err
+	}
+
+	typeCopy := make([]string, len(types))
+	for i, typeName := range types {
+		typeCopy[i] = strings.TrimSpace(typeName)
+	}
+
+	return &LeafEncoder{
+		types:      typeCopy,
+		arguments:  arguments,
+		encoding:   encoding,
+		doubleHash: doubleHash,
+	}, nil
+}
+
+// Types returns the Solidity types of each value
+func (le *LeafEncoder) Types() []string {
+	types := make([]string, len(le.types))
+	copy(types, le.types)
+	return types
+}
+
+// Encoding returns whether values are packed or ABI encoded
+func (le *LeafEncoder) Encoding() LeafEncoding {
+	return le.encoding
+}
+
+// DoubleHash reports whether the encoded values are hashed twice
+func (le *LeafEncoder) DoubleHash() bool {
+	return le.doubleHash
+}
+
+// String describes the leaf, e.g. keccak256(abi.encodePacked(address,uint256))
+func (le *LeafEncoder) String() string {
+	function := "abi.encodePacked"
+	if le.encoding == StandardEncoding {
+		function = "abi.encode"
+	}
+	description := fmt.Sprintf("keccak256(%s(%s))", function, strings.Join(le.types, ","))
+	if le.doubleHash {
+		description = fmt.Sprintf("keccak256(bytes.concat(%s))", description)
+	}
+	return description
+}
+
+// Encode serializes the values without hashing them
+// Values may be Go values (common.Address, *big.Int, ...), strings or JSON numbers
+func (le *LeafEncoder) Encode(values ...interface{}) ([]byte, error) {
+	converted, err := convertValues(le.arguments, values)
+	if err != nil {
+		return nil, err
+	}
+
+	if le.encoding == StandardEncoding {
+		encoded, err := le.arguments.Pack(converted...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to abi encode values: %w", err)
+		}
+		return encoded, nil
+	}
+
+	var encoded []byte
+	for i, value := range converted {
+		packed, err := encodePacked(le.arguments[i].Type, value, false)
+		if err != nil {
+			return nil, fmt.Errorf("failed to pack value at position %d: %w", i, err)
+		}
+		encoded = append(encoded, packed...)
+	}
+	return encoded, nil
+}
+
+// Hash encodes the values and returns the leaf
+func (le *LeafEncoder) Hash(values ...interface{}) (common.Hash, error) {
+	encoded, err := le.Encode(values...)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	leaf := crypto.Keccak256Hash(encoded)
+	if le.doubleHash {
+		leaf = crypto.Keccak256Hash(leaf[:])
+	}
+	return leaf, nil
+}
+
+// encodePacked mirrors Solidity's abi.encodePacked for one converted value
+// Array elements are padded to 32 bytes, as Solidity does
+func encodePacked(typ abi.Type, value interface{}, inArray bool) ([]byte, error) {
+	switch typ.T {
+	case abi.AddressTy:
+		address := value.(common.Address)
+		if inArray {
+			return common.LeftPadBytes(address.Bytes(), 32), nil
+		}
+		return address.Bytes(), nil
+
+	case abi.BoolTy:
+		b := []byte{0}
+		if value.(bool) {
+			b[0] = 1
+		}
+		if inArray {
+			return common.LeftPadBytes(b, 32), nil
+		}
+		return b, nil
+
+	case abi.UintTy, abi.IntTy:
+		n := integerValue(value)
+		size := typ.Size / 8
+		if inArray {
+			size = 32
+		}
+		if n.Sign() < 0 {
+			// Two's complement over the encoded width
+			n = new(big.Int).Add(n, new(big.Int).Lsh(big.NewInt(1), uint(size*8)))
+		}
+		return n.FillBytes(make([]byte, size)), nil
+
+	case abi.FixedBytesTy:
+		b := make([]byte, typ.Size)
+		reflect.Copy(reflect.ValueOf(b), reflect.ValueOf(value))
+		if inArray {
+			return common.RightPadBytes(b, 32), nil
+		}
+		return b, nil
+
+	case abi.StringTy:
+		if inArray {
+			return nil, fmt.Errorf("arrays of string cannot be packed")
+		}
+		return []byte(value.(string)), nil
+
+	case abi.BytesTy:
+		if inArray {
+			return nil, fmt.Errorf("arrays of bytes cannot be packed")
+		}
+		return value.([]byte), nil
+
+	case abi.SliceTy, abi.ArrayTy:
+		if inArray {
+			return nil, fmt.Errorf("nested arrays cannot be packed")
+		}
+		list := reflect.ValueOf(value)
+		var encoded []byte
+		for i := 0; i < list.Len(); i++ {
+			packed, err := encodePacked(*typ.Elem, list.Index(i).Interface(), true)
+			if err != nil {
+				return nil, fmt.Errorf("element %d: %w", i, err)
+			}
+			encoded = append(encoded, packed...)
+		}
+		return encoded, nil
+	}
+
+	return nil, fmt.Errorf("unsupported type %s", typ.String())
+}
+
+// integerValue returns a converted integer value (sized Go int or *big.Int) as a big.Int
+func integerValue(value interface{}) *big.Int {
+	if n, ok := value.(*big.Int); ok {
+		return n
+	}
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return new(big.Int).SetUint64(v.Uint())
+	}
+	return big.NewInt(v.Int())
+}
@@ -0,0 +1,124 @@
+package merkle
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestLeafEncoderMatchesHashAddressAmount(t *testing.T) {
+	encoder, err := NewLeafEncoder(ParseTypeList("address,uint256"), PackedEncoding, false)
+	if err != nil {
+		t.Fatalf("Failed to create encoder: %v", err)
+	}
+
+	address := common.HexToAddress("0x742d35Cc6634C0532925a3b8D4C9db96C4b4d8b6")
+	amount := big.NewInt(1000000000000000000)
+
+	leaf, err := encoder.Hash(address, amount)
+	if err != nil {
+		t.Fatalf("Failed to hash leaf: %v", err)
+	}
+	if leaf != HashAddressAmount(address, amount) {
+		t.Errorf("Packed address,uint256 leaf should match HashAddressAmount")
+	}
+
+	// String values go through the same conversion
+	leaf, _ = encoder.Hash("0x742d35Cc6634C0532925a3b8D4C9db96C4b4d8b6", "1000000000000000000")
+	if leaf != HashAddressAmount(address, amount) {
+		t.Errorf("String values should encode like Go values")
+	}
+}
+
+func TestLeafEncoderUniswapDistributor(t *testing.T) {
+	// MerkleDistributor: keccak256(abi.encodePacked(index, account, amount))
+	encoder, err := NewLeafEncoder(ParseTypeList("uint256, address, uint256"), PackedEncoding, false)
+	if err != nil {
+		t.Fatalf("Failed to create encoder: %v", err)
+	}
+
+	index := big.NewInt(7)
+	account := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	amount := big.NewInt(500)
+
+	expected := crypto.Keccak256Hash(
+		common.LeftPadBytes(index.Bytes(), 32),
+		account.Bytes(),
+		common.LeftPadBytes(amount.Bytes(), 32),
+	)
+	leaf, err := encoder.Hash(index, account, amount)
+	if err != nil {
+		t.Fatalf("Failed to hash leaf: %v", err)
+	}
+	if leaf != expected {
+		t.Errorf("Leaf mismatch:\nExpected: %s\nActual:   %s", expected.Hex(), leaf.Hex())
+	}
+}
+
+func TestLeafEncoderStandardDoubleHash(t *testing.T) {
+	encoder, err := NewLeafEncoder([]string{"address", "uint256"}, StandardEncoding, true)
+	if err != nil {
+		t.Fatalf("Failed to create encoder: %v", err)
+	}
+
+	value := standardTestValues[0]
+	leaf, err := encoder.Hash(value...)
+	if err != nil {
+		t.Fatalf("Failed to hash leaf: %v", err)
+	}
+	expected, _ := StandardLeafHash([]string{"address", "uint256"}, value)
+	if leaf != expected {
+		t.Error("Standard double-hashed leaf should match StandardLeafHash")
+	}
+
+	if encoder.String() != "keccak256(bytes.concat(keccak256(abi.encode(address,uint256))))" {
+		t.Errorf("Unexpected description: %s", encoder.String())
+	}
+}
+
+func TestEncodePackedTypes(t *testing.T) {
+	testCases := []struct {
+		name     string
+		types    string
+		values   []interface{}
+		expected string
+	}{
+		{"uint8", "uint8", []interface{}{"255"}, "ff"},
+		{"int16 negative", "int16", []interface{}{"-2"}, "fffe"},
+		{"bool", "bool", []interface{}{true}, "01"},
+		{"bytes4", "bytes4", []interface{}{"0xdeadbeef"}, "deadbeef"},
+		{"string", "string", []interface{}{"hi"}, "6869"},
+		{"bytes", "bytes", []interface{}{"0x0102"}, "0102"},
+		{"uint16 array", "uint16[]", []interface{}{[]interface{}{"1", "2"}}, "0000000000000000000000000000000000000000000000000000000000000001" + "0000000000000000000000000000000000000000000000000000000000000002"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			encoder, err := NewLeafEncoder(ParseTypeList(tc.types), PackedEncoding, false)
+			if err != nil {
+				t.Fatalf("Failed to create encoder: %v", err)
+			}
+			encoded, err := encoder.Encode(tc.values...)
+			if err != nil {
+				t.Fatalf("Failed to encode: %v", err)
+			}
+			if common.Bytes2Hex(encoded) != tc.expected {
+				t.Errorf("Expected %s, got %s", tc.expected, common.Bytes2Hex(encoded))
+			}
+		})
+	}
+}
+
+func TestLeafEncodingByName(t *testing.T) {
+	for name, expected := range map[string]LeafEncoding{"packed": PackedEncoding, "standard": StandardEncoding} {
+		encoding, err := LeafEncodingByName(name)
+		if err != nil || encoding != expected {
+			t.Errorf("Expected %s for %q, got %s (%v)", expected, name, encoding, err)
+		}
+	}
+	if _, err := LeafEncodingByName("rlp"); err == nil {
+		t.Error("Expected error for unknown encoding")
+	}
+}
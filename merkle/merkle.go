@@ -1,7 +1,6 @@
 package merkle
 
 import (
-	"bytes"
 	"errors"
 	"fmt"
 	"math/big"
@@ -13,10 +12,16 @@ import (
 // MerkleTree represents a Merkle tree structure
 type MerkleTree struct {
 	leaves []common.Hash
+	// layers holds every level of the tree, from the leaves (layers[0]) up to the root
+	layers [][]common.Hash
+	// leafIndex maps a leaf to every position holding it, in ascending order
+	leafIndex map[common.Hash][]int
+	config    treeConfig
 }
 
 // NewMerkleTree creates a new Merkle tree from the given leaves
-func NewMerkleTree(leaves []common.Hash) (*MerkleTree, error) {
+// By default nodes are combined with keccak256 over sorted pairs, like TokenClaimer.sol
+func NewMerkleTree(leaves []common.Hash, opts ...Option) (*MerkleTree, error) {
 	if len(leaves) == 0 {
 		return nil, errors.New("array cannot be empty")
 	}
@@ -25,121 +30,154 @@ func NewMerkleTree(leaves []common.Hash) (*MerkleTree, error) {
 	leafCopy := make([]common.Hash, len(leaves))
 	copy(leafCopy, leaves)
 
+	leafIndex := make(map[common.Hash][]int, len(leafCopy))
+	for i, leaf := range leafCopy {
+		leafIndex[leaf] = append(leafIndex[leaf], i)
+	}
+
+	config := newTreeConfig(opts)
+
 	return &MerkleTree{
-		leaves: leafCopy,
+		leaves:    leafCopy,
+		layers:    buildLayers(leafCopy, config),
+		leafIndex: leafIndex,
+		config:    config,
 	}, nil
 }
 
-// GenerateRoot generates the Merkle root from the leaves
-func (mt *MerkleTree) GenerateRoot() common.Hash {
-	if len(mt.leaves) == 1 {
-		return mt.leaves[0]
-	}
-
-	currentLevel := make([]common.Hash, len(mt.leaves))
-	copy(currentLevel, mt.leaves)
+// buildLayers builds the Merkle Tree level by level and returns every level
+func buildLayers(leaves []common.Hash, config treeConfig) [][]common.Hash {
+	layers := [][]common.Hash{leaves}
+	currentLevel := leaves
 
-	// Build the Merkle Tree level by level
 	for len(currentLevel) > 1 {
 		nextLevel := make([]common.Hash, (len(currentLevel)+1)/2)
 
 		for i := 0; i < len(currentLevel); i += 2 {
 			if i+1 < len(currentLevel) {
 				// Pair exists, hash them together
-				nextLevel[i/2] = hashPair(currentLevel[i], currentLevel[i+1])
+				nextLevel[i/2] = config.hashPair(currentLevel[i], currentLevel[i+1])
 			} else {
 				// Odd leaf out, promote it to next level
 				nextLevel[i/2] = currentLevel[i]
 			}
 		}
+		layers = append(layers, nextLevel)
 		currentLevel = nextLevel
 	}
 
-	return currentLevel[0]
+	return layers
+}
+
+// GenerateRoot returns the Merkle root of the tree
+func (mt *MerkleTree) GenerateRoot() common.Hash {
+	return mt.layers[len(mt.layers)-1][0]
 }
 
 // GenerateProof generates a Merkle proof for the target leaf
+// If the leaf appears more than once, the proof is for its first occurrence
 func (mt *MerkleTree) GenerateProof(target common.Hash) ([]common.Hash, error) {
-	// Find the target index
-	targetIndex := -1
-	for i, leaf := range mt.leaves {
-		if leaf == target {
-			targetIndex = i
-			break
-		}
+	indices := mt.leafIndex[target]
+	if len(indices) == 0 {
+		return nil, errors.New("target leaf not found")
 	}
 
-	if targetIndex == -1 {
-		return nil, errors.New("target leaf not found")
+	return mt.GenerateProofAt(indices[0])
+}
+
+// GenerateProofAt generates a Merkle proof for the leaf at the given position
+func (mt *MerkleTree) GenerateProofAt(index int) ([]common.Hash, error) {
+	if index < 0 || index >= len(mt.leaves) {
+		return nil, fmt.Errorf("leaf index %d out of range [0, %d)", index, len(mt.leaves))
 	}
 
 	var proof []common.Hash
-	currentLevel := make([]common.Hash, len(mt.leaves))
-	copy(currentLevel, mt.leaves)
-	currentTargetIndex := targetIndex
-
-	// Build tree and collect proof
-	for len(currentLevel) > 1 {
-		nextLevel := make([]common.Hash, (len(currentLevel)+1)/2)
-		nextTargetIndex := 0
+	currentTargetIndex := index
 
-		for i := 0; i < len(currentLevel); i += 2 {
-			if i+1 < len(currentLevel) {
-				// Pair exists
-				nextLevel[i/2] = hashPair(currentLevel[i], currentLevel[i+1])
-
-				// If target is in this pair, add sibling to proof
-				if i == (currentTargetIndex/2)*2 {
-					if currentTargetIndex%2 == 0 {
-						// Target is left child (even index), add right sibling
-						proof = append(proof, currentLevel[i+1])
-					} else {
-						// Target is right child (odd index), add left sibling
-						proof = append(proof, currentLevel[i])
-					}
-					nextTargetIndex = i / 2
-				}
-			} else {
-				// Odd leaf
-				nextLevel[i/2] = currentLevel[i]
-				if i == (currentTargetIndex/2)*2 {
-					nextTargetIndex = i / 2
-				}
-			}
+	// Walk up the cached levels and collect the sibling at each one
+	for _, level := range mt.layers[:len(mt.layers)-1] {
+		siblingIndex := currentTargetIndex ^ 1
+		if siblingIndex < len(level) {
+			proof = append(proof, level[siblingIndex])
 		}
-		currentLevel = nextLevel
-		currentTargetIndex = nextTargetIndex
+		// Without a sibling the node is promoted and contributes nothing to the proof
+		currentTargetIndex /= 2
 	}
 
 	return proof, nil
 }
 
+// FindLeafIndices returns every position holding the given leaf, in ascending order
+func (mt *MerkleTree) FindLeafIndices(leaf common.Hash) []int {
+	indices := mt.leafIndex[leaf]
+	result := make([]int, len(indices))
+	copy(result, indices)
+	return result
+}
+
+// LeafCount returns the number of leaves in the tree
+func (mt *MerkleTree) LeafCount() int {
+	return len(mt.leaves)
+}
+
 // VerifyProof verifies if a leaf is in the Merkle Tree using the provided proof
-func VerifyProof(proof []common.Hash, root common.Hash, target common.Hash) bool {
+// Positional trees cannot be verified without the leaf position, use VerifyProofAt for them
+func VerifyProof(proof []common.Hash, root common.Hash, target common.Hash, opts ...Option) bool {
+	config := newTreeConfig(opts)
+	if config.pairStrategy != SortedPairs {
+		return false
+	}
+
 	computedHash := target
 
 	for _, proofElement := range proof {
-		computedHash = hashPair(computedHash, proofElement)
+		computedHash = config.hashPair(computedHash, proofElement)
 	}
 
 	return computedHash == root
 }
 
-// hashPair hashes two nodes with consistent ordering (same as Solidity implementation)
-func hashPair(a, b common.Hash) common.Hash {
-	// Ensure a < b to maintain consistent ordering
-	if bytes.Compare(a[:], b[:]) < 0 {
-		return crypto.Keccak256Hash(append(a[:], b[:]...))
+// VerifyProofAt verifies a proof for the leaf at the given position of a tree with leafCount leaves
+// It works for every pair strategy, since the position tells which side each sibling is on
+func VerifyProofAt(proof []common.Hash, root common.Hash, target common.Hash, index int, leafCount int, opts ...Option) bool {
+	if index < 0 || index >= leafCount {
+		return false
 	}
-	return crypto.Keccak256Hash(append(b[:], a[:]...))
+	config := newTreeConfig(opts)
+
+	computedHash := target
+	proofPos := 0
+
+	// Replay the level sizes of the tree so promoted nodes are skipped like in GenerateProofAt
+	for levelSize := leafCount; levelSize > 1; levelSize = (levelSize + 1) / 2 {
+		if index^1 < levelSize {
+			if proofPos >= len(proof) {
+				return false
+			}
+			if index%2 == 0 {
+				computedHash = config.hashPair(computedHash, proof[proofPos])
+			} else {
+				computedHash = config.hashPair(proof[proofPos], computedHash)
+			}
+			proofPos++
+		}
+		index /= 2
+	}
+
+	return proofPos == len(proof) && computedHash == root
 }
 
 // Helper function to convert hex string to common.Hash
+// Only 0x-prefixed bytes32 values are accepted; use AddressToHash to pad an address
 func HexToHash(hex string) (common.Hash, error) {
-	if !common.IsHexAddress(hex) && len(hex) != 66 { // 0x + 64 chars
-		return common.Hash{}, fmt.Errorf("invalid hex string: %s", hex)
+	if !has0xPrefix(hex) {
+		return common.Hash{}, fmt.Errorf("invalid hex string %q: missing 0x prefix", hex)
+	}
+	hash, err := decodeHash(hex[2:])
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("invalid hex string %q: %v", hex, err)
 	}
-	return common.HexToHash(hex), nil
+	return hash, nil
 }
 
 // Helper function to create a hash from arbitrary data
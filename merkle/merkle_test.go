@@ -120,8 +120,9 @@ func TestHashPair(t *testing.T) {
 	b := common.HexToHash("0xfedcba0987654321fedcba0987654321fedcba0987654321fedcba0987654321")
 
 	// Hash pair should be consistent regardless of order
-	hash1 := hashPair(a, b)
-	hash2 := hashPair(b, a)
+	config := newTreeConfig(nil)
+	hash1 := config.hashPair(a, b)
+	hash2 := config.hashPair(b, a)
 
 	if hash1 != hash2 {
 		t.Error("Hash pair should be consistent regardless of input order")
@@ -145,6 +146,18 @@ func TestHexToHash(t *testing.T) {
 	if err == nil {
 		t.Error("Expected error for invalid hex")
 	}
+
+	// Addresses, short values and bad digits must not be padded or guessed at
+	for _, invalid := range []string{
+		"0x5B38Da6a701c568545dCfcB03FcB875f56beddC4",
+		"0x1234567890abcdef1234567890abcdef1234567890abcdef1234567890abcde",
+		"0x1234567890abcdef1234567890abcdef1234567890abcdef1234567890abcdeg",
+		"1234567890abcdef1234567890abcdef1234567890abcdef1234567890abcdef",
+	} {
+		if _, err := HexToHash(invalid); err == nil {
+			t.Errorf("Expected error for %s", invalid)
+		}
+	}
 }
 
 func TestConsistencyWithSolidity(t *testing.T) {
@@ -225,3 +238,93 @@ func TestHashAddressAmountWithDifferentValues(t *testing.T) {
 		})
 	}
 }
+
+func TestGenerateProofForEveryLeaf(t *testing.T) {
+	// Odd and even sizes exercise the promoted-node path on different levels
+	for size := 1; size <= 17; size++ {
+		leaves := make([]common.Hash, size)
+		for i := range leaves {
+			leaves[i] = HashData(big.NewInt(int64(i)).Bytes())
+		}
+		tree, err := NewMerkleTree(leaves)
+		if err != nil {
+			t.Fatalf("Unexpected error for %d leaves: %v", size, err)
+		}
+		root := tree.GenerateRoot()
+
+		for i, leaf := range leaves {
+			proof, err := tree.GenerateProof(leaf)
+			if err != nil {
+				t.Fatalf("Failed to generate proof for leaf %d of %d: %v", i, size, err)
+			}
+			if !VerifyProof(proof, root, leaf) {
+				t.Errorf("Proof for leaf %d of %d should verify", i, size)
+			}
+		}
+	}
+}
+
+func BenchmarkGenerateAllProofs(b *testing.B) {
+	leaves := make([]common.Hash, 10000)
+	for i := range leaves {
+		leaves[i] = HashData(big.NewInt(int64(i)).Bytes())
+	}
+
+	for n := 0; n < b.N; n++ {
+		tree, _ := NewMerkleTree(leaves)
+		for _, leaf := range leaves {
+			if _, err := tree.GenerateProof(leaf); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func TestGenerateProofAtWithDuplicateLeaves(t *testing.T) {
+	duplicate := HashData([]byte("alice"))
+	leaves := []common.Hash{
+		duplicate,
+		HashData([]byte("bob")),
+		HashData([]byte("charlie")),
+		duplicate,
+		HashData([]byte("dave")),
+	}
+	tree, _ := NewMerkleTree(leaves)
+	root := tree.GenerateRoot()
+
+	indices := tree.FindLeafIndices(duplicate)
+	if len(indices) != 2 || indices[0] != 0 || indices[1] != 3 {
+		t.Fatalf("Expected indices [0 3], got %v", indices)
+	}
+
+	firstProof, err := tree.GenerateProofAt(0)
+	if err != nil {
+		t.Fatalf("Failed to generate proof at 0: %v", err)
+	}
+	secondProof, err := tree.GenerateProofAt(3)
+	if err != nil {
+		t.Fatalf("Failed to generate proof at 3: %v", err)
+	}
+	if !VerifyProof(firstProof, root, duplicate) || !VerifyProof(secondProof, root, duplicate) {
+		t.Error("Both occurrences of a duplicate leaf should have valid proofs")
+	}
+	if len(firstProof) == len(secondProof) && firstProof[0] == secondProof[0] {
+		t.Error("Proofs for different positions should differ")
+	}
+
+	// GenerateProof keeps returning the proof for the first occurrence
+	proof, _ := tree.GenerateProof(duplicate)
+	if len(proof) != len(firstProof) || proof[0] != firstProof[0] {
+		t.Error("GenerateProof should match the proof for the first occurrence")
+	}
+
+	if len(tree.FindLeafIndices(HashData([]byte("eve")))) != 0 {
+		t.Error("Expected no indices for a missing leaf")
+	}
+
+	for _, index := range []int{-1, len(leaves)} {
+		if _, err := tree.GenerateProofAt(index); err == nil {
+			t.Errorf("Expected error for out of range index %d", index)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package merkle
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// MultiProof proves several leaves at once, in the format expected by OpenZeppelin's MerkleProof.multiProofVerify.
+// Leaves must be passed to the verifier in the order given here.
+type MultiProof struct {
+	Leaves     []common.Hash `json:"leaves"`
+	Proof      []common.Hash `json:"proof"`
+	ProofFlags []bool        `json:"proofFlags"`
+	// Values holds the values behind Leaves, in the same order, when generated from a StandardMerkleTree
+	Values [][]interface{} `json:"values,omitempty"`
+}
+
+// GenerateMultiProofAt generates a multiproof for the values at the given indices, like getMultiProof(indices)
+func (st *StandardMerkleTree) GenerateMultiProofAt(indices []int) (*MultiProof, error) {
+	treeIndices := make([]int, len(indices))
+	for i, index := range indices {
+		if index < 0 || index >= len(st.values) {
+			return nil, fmt.Errorf("value index %d out of range [0, %d)", index, len(st.values))
+		}
+		treeIndices[i] = st.values[index].TreeIndex
+	}
+
+	multiProof, err := generateMultiProof(st.tree, treeIndices)
+	if err != nil {
+		return nil, err
+	}
+
+	multiProof.Values = make([][]interface{}, len(multiProof.Leaves))
+	for i, leaf := range multiProof.Leaves {
+		multiProof.Values[i] = st.values[st.hashIndex[leaf]].Value
+	}
+
+	return multiProof, nil
+}
+
+// GenerateMultiProof generates a multiproof for the given values, like getMultiProof(values)
+func (st *StandardMerkleTree) GenerateMultiProof(values [][]interface{}) (*MultiProof, error) {
+	indices := make([]int, len(values))
+	for i, value := range values {
+		index, err := st.LeafLookup(value)
+		if err != nil {
+			return nil, fmt.Errorf("value %d: %w", i, err)
+		}
+		indices[i] = index
+	}
+	return st.GenerateMultiProofAt(indices)
+}
+
+// generateMultiProof builds a multiproof for leaves at the given positions of an array-backed complete binary tree
+func generateMultiProof(tree []common.Hash, treeIndices []int) (*MultiProof, error) {
+	firstLeaf := len(tree) / 2
+	for _, index := range treeIndices {
+		if index < firstLeaf || index >= len(tree) {
+			return nil, fmt.Errorf("tree index %d is not a leaf", index)
+		}
+	}
+
+	// Process leaves from the deepest, right-most position so siblings are adjacent in the queue
+	queue := make([]int, len(treeIndices))
+	copy(queue, treeIndices)
+	sort.Sort(sort.Reverse(sort.IntSlice(queue)))
+	for i := 1; i < len(queue); i++ {
+		if queue[i] == queue[i-1] {
+			return nil, errors.New("cannot prove duplicated index")
+		}
+	}
+
+	leaves := make([]common.Hash, len(queue))
+	for i, index := range queue {
+		leaves[i] = tree[index]
+	}
+
+	proof := []common.Hash{}
+	proofFlags := []bool{}
+	for len(queue) > 0 && queue[0] > 0 {
+		j := queue[0]
+		queue = queue[1:]
+
+		sibling := j + 1
+		if j%2 == 0 {
+			sibling = j - 1
+		}
+
+		if len(queue) > 0 && queue[0] == sibling {
+			// Sibling is already known, consume it from the queue
+			proofFlags = append(proofFlags, true)
+			queue = queue[1:]
+		} else {
+			proofFlags = append(proofFlags, false)
+			proof = append(proof, tree[sibling])
+		}
+		queue = append(queue, (j-1)/2)
+	}
+
+	if len(treeIndices) == 0 {
+		proof = append(proof, tree[0])
+	}
+
+	return &MultiProof{
+		Leaves:     leaves,
+		Proof:      proof,
+		ProofFlags: proofFlags,
+	}, nil
+}
+
+// ProcessMultiProof reconstructs the root from a multiproof, like MerkleProof.processMultiProof
+func ProcessMultiProof(proof []common.Hash, proofFlags []bool, leaves []common.Hash) (common.Hash, error) {
+	if len(leaves)+len(proof) != len(proofFlags)+1 {
+		return common.Hash{}, errors.New("invalid multiproof: leaves and proof do not match proof flags")
+	}
+
+	// Leaves are consumed first, then the hashes computed so far, in order
+	stack := make([]common.Hash, 0, len(leaves)+len(proofFlags))
+	stack = append(stack, leaves...)
+	proofPos := 0
+
+	for _, flag := range proofFlags {
+		if len(stack) == 0 {
+			return common.Hash{}, errors.New("invalid multiproof: not enough leaves")
+		}
+		a := stack[0]
+		stack = stack[1:]
+
+		var b common.Hash
+		if flag {
+			if len(stack) == 0 {
+				return common.Hash{}, errors.New("invalid multiproof: not enough leaves")
+			}
+			b = stack[0]
+			stack = stack[1:]
+		} else {
+			if proofPos >= len(proof) {
+				return common.Hash{}, errors.New("invalid multiproof: not enough proof elements")
+			}
+			b = proof[proofPos]
+			proofPos++
+		}
+		stack = append(stack, standardConfig.hashPair(a, b))
+	}
+
+	if len(proofFlags) > 0 {
+		if proofPos != len(proof) {
+			return common.Hash{}, errors.New("invalid multiproof: unused proof elements")
+		}
+		return stack[len(stack)-1], nil
+	}
+	if len(leaves) > 0 {
+		return leaves[0], nil
+	}
+	return proof[0], nil
+}
+
+// VerifyMultiProof verifies that all leaves are in the tree, like MerkleProof.multiProofVerify
+func VerifyMultiProof(proof []common.Hash, proofFlags []bool, root common.Hash, leaves []common.Hash) bool {
+	computedRoot, err := ProcessMultiProof(proof, proofFlags, leaves)
+	if err != nil {
+		return false
+	}
+	return computedRoot == root
+}
@@ -0,0 +1,83 @@
+package merkle
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestStandardMultiProof(t *testing.T) {
+	values := make([][]interface{}, 7)
+	for i := range values {
+		values[i] = []interface{}{fmt.Sprintf("0x%040x", i+1), fmt.Sprintf("%d", (i+1)*100)}
+	}
+	tree, err := NewStandardMerkleTree(values, []string{"address", "uint256"})
+	if err != nil {
+		t.Fatalf("Failed to build standard tree: %v", err)
+	}
+	root := tree.GenerateRoot()
+
+	indexSets := [][]int{
+		{0},
+		{0, 1},
+		{6, 2, 4},
+		{0, 1, 2, 3, 4, 5, 6},
+		{},
+	}
+	for _, indices := range indexSets {
+		multiProof, err := tree.GenerateMultiProofAt(indices)
+		if err != nil {
+			t.Fatalf("Failed to generate multiproof for %v: %v", indices, err)
+		}
+		if len(multiProof.Values) != len(indices) {
+			t.Errorf("Expected %d values for %v, got %d", len(indices), indices, len(multiProof.Values))
+		}
+		if !VerifyMultiProof(multiProof.Proof, multiProof.ProofFlags, root, multiProof.Leaves) {
+			t.Errorf("Multiproof for %v should verify", indices)
+		}
+
+		// Values are returned in leaf order so the contract can rebuild the leaves
+		for i, value := range multiProof.Values {
+			leaf, _ := tree.LeafHash(value)
+			if leaf != multiProof.Leaves[i] {
+				t.Errorf("Value %d does not match leaf %d for %v", i, i, indices)
+			}
+		}
+	}
+
+	if _, err := tree.GenerateMultiProofAt([]int{1, 1}); err == nil {
+		t.Error("Expected error for duplicated index")
+	}
+	if _, err := tree.GenerateMultiProofAt([]int{7}); err == nil {
+		t.Error("Expected error for out of range index")
+	}
+}
+
+func TestVerifyMultiProofRejectsTampering(t *testing.T) {
+	values := [][]interface{}{
+		{"0x1111111111111111111111111111111111111111", "1"},
+		{"0x2222222222222222222222222222222222222222", "2"},
+		{"0x3333333333333333333333333333333333333333", "3"},
+		{"0x4444444444444444444444444444444444444444", "4"},
+	}
+	tree, _ := NewStandardMerkleTree(values, []string{"address", "uint256"})
+	root := tree.GenerateRoot()
+
+	multiProof, err := tree.GenerateMultiProofAt([]int{0, 3})
+	if err != nil {
+		t.Fatalf("Failed to generate multiproof: %v", err)
+	}
+
+	leaves := append(multiProof.Leaves[:0:0], multiProof.Leaves...)
+	leaves[0] = HashData([]byte("eve"))
+	if VerifyMultiProof(multiProof.Proof, multiProof.ProofFlags, root, leaves) {
+		t.Error("Multiproof with a wrong leaf should not verify")
+	}
+
+	if VerifyMultiProof(multiProof.Proof, multiProof.ProofFlags, HashData([]byte("wrong")), multiProof.Leaves) {
+		t.Error("Multiproof with wrong root should not verify")
+	}
+
+	if VerifyMultiProof(multiProof.Proof, multiProof.ProofFlags[1:], root, multiProof.Leaves) {
+		t.Error("Multiproof with missing flags should not verify")
+	}
+}
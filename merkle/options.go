@@ -0,0 +1,143 @@
+package merkle
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"golang.org/x/crypto/blake2b"
+	"golang.org/x/crypto/sha3"
+)
+
+// HashFunc hashes the concatenation of the given byte slices to 32 bytes
+type HashFunc func(data ...[]byte) common.Hash
+
+// Keccak256 is the Ethereum keccak256 hash, the default for all trees
+func Keccak256(data ...[]byte) common.Hash {
+	return crypto.Keccak256Hash(data...)
+}
+
+// SHA256 is the SHA-256 hash used by Bitcoin-style trees
+func SHA256(data ...[]byte) common.Hash {
+	h := sha256.New()
+	for _, d := range data {
+		h.Write(d)
+	}
+	return common.BytesToHash(h.Sum(nil))
+}
+
+// SHA3256 is the NIST SHA3-256 hash (not the same as keccak256)
+func SHA3256(data ...[]byte) common.Hash {
+	h := sha3.New256()
+	for _, d := range data {
+		h.Write(d)
+	}
+	return common.BytesToHash(h.Sum(nil))
+}
+
+// Blake2b256 is BLAKE2b with a 32-byte digest
+func Blake2b256(data ...[]byte) common.Hash {
+	h, _ := blake2b.New256(nil) // only fails for keys longer than 64 bytes
+	for _, d := range data {
+		h.Write(d)
+	}
+	return common.BytesToHash(h.Sum(nil))
+}
+
+// hashFuncs maps the names accepted by HashFuncByName to their implementations
+var hashFuncs = map[string]HashFunc{
+	"keccak256": Keccak256,
+	"sha256":    SHA256,
+	"sha3-256":  SHA3256,
+	"blake2b":   Blake2b256,
+}
+
+// HashFuncByName returns the hash function for keccak256, sha256, sha3-256 or blake2b
+func HashFuncByName(name string) (HashFunc, error) {
+	h, ok := hashFuncs[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("unknown hash function %q (expected keccak256, sha256, sha3-256 or blake2b)", name)
+	}
+	return h, nil
+}
+
+// PairStrategy decides how two sibling nodes are combined
+type PairStrategy int
+
+const (
+	// SortedPairs hashes the smaller node first, so proofs need no direction (OpenZeppelin, TokenClaimer.sol)
+	SortedPairs PairStrategy = iota
+	// PositionalPairs hashes the left node first, so verification needs the leaf position
+	PositionalPairs
+)
+
+// String returns the name accepted by PairStrategyByName
+func (p PairStrategy) String() string {
+	switch p {
+	case SortedPairs:
+		return "sorted"
+	case PositionalPairs:
+		return "positional"
+	}
+	return fmt.Sprintf("PairStrategy(%d)", int(p))
+}
+
+// PairStrategyByName returns the pair strategy for "sorted" or "positional"
+func PairStrategyByName(name string) (PairStrategy, error) {
+	switch strings.ToLower(name) {
+	case "sorted":
+		return SortedPairs, nil
+	case "positional":
+		return PositionalPairs, nil
+	}
+	return 0, fmt.Errorf("unknown pair strategy %q (expected sorted or positional)", name)
+}
+
+// Option configures how a tree hashes its nodes
+type Option func(*treeConfig)
+
+// WithHashFunc sets the hash function used to combine nodes (default Keccak256)
+func WithHashFunc(h HashFunc) Option {
+	return func(c *treeConfig) {
+		c.hashFunc = h
+	}
+}
+
+// WithPairStrategy sets how sibling nodes are ordered before hashing (default SortedPairs)
+func WithPairStrategy(p PairStrategy) Option {
+	return func(c *treeConfig) {
+		c.pairStrategy = p
+	}
+}
+
+// treeConfig holds the options a tree was built with
+type treeConfig struct {
+	hashFunc     HashFunc
+	pairStrategy PairStrategy
+}
+
+// standardConfig is the fixed hashing of OpenZeppelin's StandardMerkleTree and multiproofs:
+// keccak256 over sorted pairs
+var standardConfig = newTreeConfig(nil)
+
+func newTreeConfig(opts []Option) treeConfig {
+	config := treeConfig{
+		hashFunc:     Keccak256,
+		pairStrategy: SortedPairs,
+	}
+	for _, opt := range opts {
+		opt(&config)
+	}
+	return config
+}
+
+// hashPair combines a left and a right node according to the configured strategy
+func (c treeConfig) hashPair(left, right common.Hash) common.Hash {
+	if c.pairStrategy == SortedPairs && bytes.Compare(left[:], right[:]) > 0 {
+		left, right = right, left
+	}
+	return c.hashFunc(left[:], right[:])
+}
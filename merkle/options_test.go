@@ -0,0 +1,112 @@
+package merkle
+
+import (
+	"crypto/sha256"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestHashFuncByName(t *testing.T) {
+	for _, name := range []string{"keccak256", "sha256", "sha3-256", "blake2b", "SHA256"} {
+		if _, err := HashFuncByName(name); err != nil {
+			t.Errorf("Unexpected error for %s: %v", name, err)
+		}
+	}
+	if _, err := HashFuncByName("md5"); err == nil {
+		t.Error("Expected error for unknown hash function")
+	}
+
+	// Keccak256 and SHA3-256 differ only in padding and must not be confused
+	data := []byte("alice")
+	if Keccak256(data) == SHA3256(data) {
+		t.Error("Keccak256 and SHA3-256 should produce different hashes")
+	}
+	if Keccak256(data) != HashData(data) {
+		t.Error("Keccak256 should match HashData")
+	}
+	if SHA256(data) != common.Hash(sha256.Sum256(data)) {
+		t.Error("SHA256 should match crypto/sha256")
+	}
+}
+
+func TestPositionalSHA256Tree(t *testing.T) {
+	a := SHA256([]byte("a"))
+	b := SHA256([]byte("b"))
+
+	// Positional pairs keep the left node first even when it sorts higher
+	left, right := b, a
+	tree, err := NewMerkleTree([]common.Hash{left, right}, WithHashFunc(SHA256), WithPairStrategy(PositionalPairs))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := SHA256(left[:], right[:])
+	if root := tree.GenerateRoot(); root != expected {
+		t.Errorf("Positional root mismatch:\nExpected: %s\nActual:   %s", expected.Hex(), root.Hex())
+	}
+
+	proof, _ := tree.GenerateProofAt(0)
+	if !VerifyProofAt(proof, expected, left, 0, 2, WithHashFunc(SHA256), WithPairStrategy(PositionalPairs)) {
+		t.Error("Positional proof should verify at its own position")
+	}
+	if VerifyProofAt(proof, expected, left, 1, 2, WithHashFunc(SHA256), WithPairStrategy(PositionalPairs)) {
+		t.Error("Positional proof should not verify at another position")
+	}
+
+	sorted, _ := NewMerkleTree([]common.Hash{right, left}, WithHashFunc(SHA256))
+	reversed, _ := NewMerkleTree([]common.Hash{left, right}, WithHashFunc(SHA256))
+	if sorted.GenerateRoot() != reversed.GenerateRoot() {
+		t.Error("Sorted pairs should not depend on leaf order")
+	}
+}
+
+func TestVerifyProofAtWithOptions(t *testing.T) {
+	optionSets := map[string][]Option{
+		"keccak256 sorted":     nil,
+		"sha256 positional":    {WithHashFunc(SHA256), WithPairStrategy(PositionalPairs)},
+		"sha3-256 positional":  {WithHashFunc(SHA3256), WithPairStrategy(PositionalPairs)},
+		"blake2b sorted":       {WithHashFunc(Blake2b256)},
+		"keccak256 positional": {WithPairStrategy(PositionalPairs)},
+	}
+
+	for name, opts := range optionSets {
+		t.Run(name, func(t *testing.T) {
+			for size := 1; size <= 9; size++ {
+				leaves := make([]common.Hash, size)
+				for i := range leaves {
+					leaves[i] = HashData([]byte{byte(i)})
+				}
+				tree, _ := NewMerkleTree(leaves, opts...)
+				root := tree.GenerateRoot()
+
+				for i, leaf := range leaves {
+					proof, _ := tree.GenerateProofAt(i)
+					if !VerifyProofAt(proof, root, leaf, i, size, opts...) {
+						t.Errorf("Proof for leaf %d of %d should verify", i, size)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestVerifyProofWithOptions(t *testing.T) {
+	leaves := []common.Hash{
+		HashData([]byte("alice")),
+		HashData([]byte("bob")),
+		HashData([]byte("charlie")),
+	}
+	tree, _ := NewMerkleTree(leaves, WithHashFunc(SHA256))
+	root := tree.GenerateRoot()
+	proof, _ := tree.GenerateProof(leaves[1])
+
+	if !VerifyProof(proof, root, leaves[1], WithHashFunc(SHA256)) {
+		t.Error("Proof should verify with the hash function the tree was built with")
+	}
+	if VerifyProof(proof, root, leaves[1]) {
+		t.Error("Proof should not verify with a different hash function")
+	}
+	if VerifyProof(proof, root, leaves[1], WithHashFunc(SHA256), WithPairStrategy(PositionalPairs)) {
+		t.Error("Positional proofs cannot be verified without the leaf position")
+	}
+}
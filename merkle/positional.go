@@ -0,0 +1,73 @@
+package merkle
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// PositionalProof is a Merkle proof that also records where the leaf sits in the tree
+type PositionalProof struct {
+	LeafIndex int           `json:"leafIndex"`
+	Siblings  []common.Hash `json:"siblings"`
+	// Directions[i] is true when the node being proven is the right child at that step,
+	// so Siblings[i] is hashed on the left
+	Directions []bool `json:"directions"`
+}
+
+// PathBitmap packs Directions into an integer with bit i set when Directions[i] is true,
+// the form most on-chain positional verifiers take
+func (p *PositionalProof) PathBitmap() *big.Int {
+	path := new(big.Int)
+	for i, right := range p.Directions {
+		if right {
+			path.SetBit(path, i, 1)
+		}
+	}
+	return path
+}
+
+// GeneratePositionalProof generates a proof with direction bits for the leaf at the given position
+func (mt *MerkleTree) GeneratePositionalProof(index int) (*PositionalProof, error) {
+	if index < 0 || index >= len(mt.leaves) {
+		return nil, fmt.Errorf("leaf index %d out of range [0, %d)", index, len(mt.leaves))
+	}
+
+	proof := &PositionalProof{
+		LeafIndex:  index,
+		Siblings:   []common.Hash{},
+		Directions: []bool{},
+	}
+	currentIndex := index
+
+	for _, level := range mt.layers[:len(mt.layers)-1] {
+		siblingIndex := currentIndex ^ 1
+		if siblingIndex < len(level) {
+			proof.Siblings = append(proof.Siblings, level[siblingIndex])
+			proof.Directions = append(proof.Directions, currentIndex%2 == 1)
+		}
+		currentIndex /= 2
+	}
+
+	return proof, nil
+}
+
+// VerifyPositionalProof verifies a positional proof, placing each sibling on the side given by its direction bit
+func VerifyPositionalProof(proof *PositionalProof, root common.Hash, target common.Hash, opts ...Option) bool {
+	if proof == nil || len(proof.Siblings) != len(proof.Directions) {
+		return false
+	}
+	config := newTreeConfig(opts)
+
+	computedHash := target
+	for i, sibling := range proof.Siblings {
+		if proof.Directions[i] {
+			computedHash = config.hashPair(sibling, computedHash)
+		} else {
+			computedHash = config.hashPair(computedHash, sibling)
+		}
+	}
+
+	return computedHash == root
+}
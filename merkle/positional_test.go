@@ -0,0 +1,78 @@
+package merkle
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestPositionalProof(t *testing.T) {
+	opts := []Option{WithHashFunc(SHA256), WithPairStrategy(PositionalPairs)}
+
+	for size := 1; size <= 9; size++ {
+		leaves := make([]common.Hash, size)
+		for i := range leaves {
+			leaves[i] = HashData([]byte{byte(i)})
+		}
+		tree, _ := NewMerkleTree(leaves, opts...)
+		root := tree.GenerateRoot()
+
+		for i, leaf := range leaves {
+			proof, err := tree.GeneratePositionalProof(i)
+			if err != nil {
+				t.Fatalf("Failed to generate positional proof for leaf %d of %d: %v", i, size, err)
+			}
+			if proof.LeafIndex != i {
+				t.Errorf("Expected leaf index %d, got %d", i, proof.LeafIndex)
+			}
+
+			// The siblings are the same hashes GenerateProofAt returns
+			plain, _ := tree.GenerateProofAt(i)
+			if len(plain) != len(proof.Siblings) {
+				t.Fatalf("Expected %d siblings, got %d", len(plain), len(proof.Siblings))
+			}
+
+			if !VerifyPositionalProof(proof, root, leaf, opts...) {
+				t.Errorf("Positional proof for leaf %d of %d should verify", i, size)
+			}
+		}
+	}
+}
+
+func TestPositionalProofDirections(t *testing.T) {
+	leaves := []common.Hash{
+		HashData([]byte("alice")),
+		HashData([]byte("bob")),
+		HashData([]byte("charlie")),
+		HashData([]byte("dave")),
+		HashData([]byte("eve")),
+	}
+	opts := []Option{WithPairStrategy(PositionalPairs)}
+	tree, _ := NewMerkleTree(leaves, opts...)
+	root := tree.GenerateRoot()
+
+	// Leaf 3 is a right child, then a right child again, then the left of the promoted leaf 4
+	proof, _ := tree.GeneratePositionalProof(3)
+	expected := []bool{true, true, false}
+	if len(proof.Directions) != len(expected) {
+		t.Fatalf("Expected %d directions, got %d", len(expected), len(proof.Directions))
+	}
+	for i := range expected {
+		if proof.Directions[i] != expected[i] {
+			t.Errorf("Direction %d: expected %t, got %t", i, expected[i], proof.Directions[i])
+		}
+	}
+	if proof.PathBitmap().Uint64() != 3 {
+		t.Errorf("Expected path bitmap 3, got %s", proof.PathBitmap().String())
+	}
+
+	// Flipping a direction must break a positional proof
+	proof.Directions[0] = !proof.Directions[0]
+	if VerifyPositionalProof(proof, root, leaves[3], opts...) {
+		t.Error("Proof with a wrong direction should not verify")
+	}
+
+	if _, err := tree.GeneratePositionalProof(len(leaves)); err == nil {
+		t.Error("Expected error for out of range index")
+	}
+}
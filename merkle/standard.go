@@ -0,0 +1,247 @@
+package merkle
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// StandardFormat is the format tag written by StandardMerkleTree.dump() in @openzeppelin/merkle-tree
+const StandardFormat = "standard-v1"
+
+// StandardMerkleTree is a Go port of StandardMerkleTree from the @openzeppelin/merkle-tree JS library.
+// Leaves are keccak256(keccak256(abi.encode(...values))), sorted, and laid out as a complete
+// binary tree stored in an array with the root at index 0.
+type StandardMerkleTree struct {
+	tree    []common.Hash
+	values  []StandardValue
+	encoder *LeafEncoder
+	// hashIndex maps a leaf hash to the index of its value; like hashLookup in the JS library, a
+	// value given more than once maps to its last occurrence
+	hashIndex map[common.Hash]int
+}
+
+// StandardValue is a value in the tree together with the position of its leaf in the tree array
+type StandardValue struct {
+	Value     []interface{} `json:"value"`
+	TreeIndex int           `json:"treeIndex"`
+}
+
+// StandardMerkleTreeData is the JSON representation produced by dump() and accepted by load()
+type StandardMerkleTreeData struct {
+	Format       string          `json:"format"`
+	LeafEncoding []string        `json:"leafEncoding"`
+	Tree         []common.Hash   `json:"tree"`
+	Values       []StandardValue `json:"values"`
+}
+
+// NewStandardMerkleTree builds a tree from values encoded with the given Solidity types, like StandardMerkleTree.of
+func NewStandardMerkleTree(values [][]interface{}, leafEncoding []string) (*StandardMerkleTree, error) {
+	if len(values) == 0 {
+		return nil, errors.New("array cannot be empty")
+	}
+
+	encoder, err := NewLeafEncoder(leafEncoding, StandardEncoding, true)
+	if err != nil {
+		return nil, err
+	}
+
+	type hashedValue struct {
+		valueIndex int
+		hash       common.Hash
+	}
+	hashedValues := make([]hashedValue, len(values))
+	for i, value := range values {
+		hash, err := encoder.Hash(value...)
+		if err != nil {
+			return nil, fmt.Errorf("value %d: %w", i, err)
+		}
+		hashedValues[i] = hashedValue{valueIndex: i, hash: hash}
+	}
+
+	sort.SliceStable(hashedValues, func(i, j int) bool {
+		return bytes.Compare(hashedValues[i].hash[:], hashedValues[j].hash[:]) < 0
+	})
+
+	leaves := make([]common.Hash, len(hashedValues))
+	for i, hv := range hashedValues {
+		leaves[i] = hv.hash
+	}
+	tree := makeStandardTree(leaves)
+
+	indexedValues := make([]StandardValue, len(values))
+	for leafIndex, hv := range hashedValues {
+		indexedValues[hv.valueIndex] = StandardValue{
+			Value:     values[hv.valueIndex],
+			TreeIndex: len(tree) - leafIndex - 1,
+		}
+	}
+
+	return newStandardMerkleTree(tree, indexedValues, encoder), nil
+}
+
+// LoadStandardMerkleTree restores a tree from dump() output and checks that it is consistent
+func LoadStandardMerkleTree(data StandardMerkleTreeData) (*StandardMerkleTree, error) {
+	if data.Format != StandardFormat {
+		return nil, fmt.Errorf("unknown format '%s'", data.Format)
+	}
+	if len(data.Tree) == 0 {
+		return nil, errors.New("tree cannot be empty")
+	}
+
+	encoder, err := NewLeafEncoder(data.LeafEncoding, StandardEncoding, true)
+	if err != nil {
+		return nil, err
+	}
+
+	// Every internal node must be the hash of its two children
+	for i := 0; 2*i+2 < len(data.Tree); i++ {
+		if data.Tree[i] != standardConfig.hashPair(data.Tree[2*i+1], data.Tree[2*i+2]) {
+			return nil, fmt.Errorf("invalid merkle tree: node %d does not match its children", i)
+		}
+	}
+
+	firstLeaf := len(data.Tree) / 2
+	for i, value := range data.Values {
+		if value.TreeIndex < firstLeaf || value.TreeIndex >= len(data.Tree) {
+			return nil, fmt.Errorf("value %d: tree index %d is not a leaf", i, value.TreeIndex)
+		}
+		hash, err := encoder.Hash(value.Value...)
+		if err != nil {
+			return nil, fmt.Errorf("value %d: %w", i, err)
+		}
+		if hash != data.Tree[value.TreeIndex] {
+			return nil, fmt.Errorf("value %d: leaf hash does not match the tree", i)
+		}
+	}
+
+	tree := make([]common.Hash, len(data.Tree))
+	copy(tree, data.Tree)
+	values := make([]StandardValue, len(data.Values))
+	copy(values, data.Values)
+
+	return newStandardMerkleTree(tree, values, encoder), nil
+}
+
+func newStandardMerkleTree(tree []common.Hash, values []StandardValue, encoder *LeafEncoder) *StandardMerkleTree {
+	hashIndex := make(map[common.Hash]int, len(values))
+	for i, value := range values {
+		hashIndex[tree[value.TreeIndex]] = i
+	}
+
+	return &StandardMerkleTree{
+		tree:      tree,
+		values:    values,
+		encoder:   encoder,
+		hashIndex: hashIndex,
+	}
+}
+
+// makeStandardTree lays the leaves out in reverse at the end of the array and fills in parents
+func makeStandardTree(leaves []common.Hash) []common.Hash {
+	tree := make([]common.Hash, 2*len(leaves)-1)
+	for i, leaf := range leaves {
+		tree[len(tree)-1-i] = leaf
+	}
+	for i := len(tree) - 1 - len(leaves); i >= 0; i-- {
+		tree[i] = standardConfig.hashPair(tree[2*i+1], tree[2*i+2])
+	}
+	return tree
+}
+
+// GenerateRoot returns the Merkle root of the tree
+func (st *StandardMerkleTree) GenerateRoot() common.Hash {
+	return st.tree[0]
+}
+
+// GenerateProofAt generates a proof for the value at the given index, like getProof(index)
+func (st *StandardMerkleTree) GenerateProofAt(index int) ([]common.Hash, error) {
+	if index < 0 || index >= len(st.values) {
+		return nil, fmt.Errorf("value index %d out of range [0, %d)", index, len(st.values))
+	}
+
+	proof := []common.Hash{}
+	for treeIndex := st.values[index].TreeIndex; treeIndex > 0; treeIndex = (treeIndex - 1) / 2 {
+		// Odd positions are left children, so their sibling is on the right
+		sibling := treeIndex + 1
+		if treeIndex%2 == 0 {
+			sibling = treeIndex - 1
+		}
+		proof = append(proof, st.tree[sibling])
+	}
+
+	return proof, nil
+}
+
+// GenerateProof generates a proof for the given value, like getProof(value)
+func (st *StandardMerkleTree) GenerateProof(value []interface{}) ([]common.Hash, error) {
+	index, err := st.LeafLookup(value)
+	if err != nil {
+		return nil, err
+	}
+	return st.GenerateProofAt(index)
+}
+
+// LeafHash returns the double-hashed leaf for a value using the tree's leaf encoding
+func (st *StandardMerkleTree) LeafHash(value []interface{}) (common.Hash, error) {
+	return st.encoder.Hash(value...)
+}
+
+// LeafLookup returns the index of the given value, like leafLookup(value)
+func (st *StandardMerkleTree) LeafLookup(value []interface{}) (int, error) {
+	hash, err := st.LeafHash(value)
+	if err != nil {
+		return -1, err
+	}
+	index, ok := st.hashIndex[hash]
+	if !ok {
+		return -1, errors.New("leaf is not in tree")
+	}
+	return index, nil
+}
+
+// Values returns the values in their original order with their tree positions
+func (st *StandardMerkleTree) Values() []StandardValue {
+	values := make([]StandardValue, len(st.values))
+	copy(values, st.values)
+	return values
+}
+
+// LeafEncoding returns the Solidity types used to encode each value
+func (st *StandardMerkleTree) LeafEncoding() []string {
+	return st.encoder.Types()
+}
+
+// Dump returns the tree in the same shape as StandardMerkleTree.dump()
+func (st *StandardMerkleTree) Dump() StandardMerkleTreeData {
+	tree := make([]common.Hash, len(st.tree))
+	copy(tree, st.tree)
+
+	return StandardMerkleTreeData{
+		Format:       StandardFormat,
+		LeafEncoding: st.LeafEncoding(),
+		Tree:         tree,
+		Values:       st.Values(),
+	}
+}
+
+// StandardLeafHash computes keccak256(bytes.concat(keccak256(abi.encode(...values)))) for the given Solidity types
+func StandardLeafHash(leafEncoding []string, value []interface{}) (common.Hash, error) {
+	encoder, err := NewLeafEncoder(leafEncoding, StandardEncoding, true)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return encoder.Hash(value...)
+}
+
+// VerifyStandardProof verifies a proof for a value against a root, like StandardMerkleTree.verify
+func VerifyStandardProof(root common.Hash, leafEncoding []string, value []interface{}, proof []common.Hash) (bool, error) {
+	leaf, err := StandardLeafHash(leafEncoding, value)
+	if err != nil {
+		return false, err
+	}
+	return VerifyProof(proof, root, leaf), nil
+}
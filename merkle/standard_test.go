@@ -0,0 +1,150 @@
+package merkle
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var standardTestValues = [][]interface{}{
+	{"0x1111111111111111111111111111111111111111", "5000000000000000000"},
+	{"0x2222222222222222222222222222222222222222", "2500000000000000000"},
+}
+
+func TestStandardMerkleTreeMatchesOpenZeppelin(t *testing.T) {
+	// Root from the @openzeppelin/merkle-tree README for the same values
+	expectedRoot := common.HexToHash("0xd4dee0beab2d53f2cc83e567171bd2820e49898130a22622b10ead383e90bd77")
+
+	tree, err := NewStandardMerkleTree(standardTestValues, []string{"address", "uint256"})
+	if err != nil {
+		t.Fatalf("Failed to build standard tree: %v", err)
+	}
+
+	if root := tree.GenerateRoot(); root != expectedRoot {
+		t.Errorf("Standard tree root mismatch:\nExpected: %s\nActual:   %s", expectedRoot.Hex(), root.Hex())
+	}
+}
+
+func TestStandardMerkleTreeProofs(t *testing.T) {
+	values := [][]interface{}{
+		{"0x1111111111111111111111111111111111111111", "1"},
+		{"0x2222222222222222222222222222222222222222", "2"},
+		{"0x3333333333333333333333333333333333333333", "3"},
+		{"0x4444444444444444444444444444444444444444", "4"},
+		{"0x5555555555555555555555555555555555555555", "5"},
+	}
+	encoding := []string{"address", "uint256"}
+
+	tree, err := NewStandardMerkleTree(values, encoding)
+	if err != nil {
+		t.Fatalf("Failed to build standard tree: %v", err)
+	}
+	root := tree.GenerateRoot()
+
+	for i, value := range values {
+		proof, err := tree.GenerateProofAt(i)
+		if err != nil {
+			t.Fatalf("Failed to generate proof for value %d: %v", i, err)
+		}
+
+		valid, err := VerifyStandardProof(root, encoding, value, proof)
+		if err != nil {
+			t.Fatalf("Unexpected error verifying value %d: %v", i, err)
+		}
+		if !valid {
+			t.Errorf("Proof for value %d should verify", i)
+		}
+
+		byValue, err := tree.GenerateProof(value)
+		if err != nil {
+			t.Fatalf("Failed to generate proof by value %d: %v", i, err)
+		}
+		if len(byValue) != len(proof) {
+			t.Errorf("Proof by value and by index differ for value %d", i)
+		}
+	}
+
+	if _, err := tree.GenerateProof([]interface{}{"0x6666666666666666666666666666666666666666", "6"}); err == nil {
+		t.Error("Expected error for value not in tree")
+	}
+}
+
+func TestStandardMerkleTreeDuplicateValues(t *testing.T) {
+	values := [][]interface{}{
+		{"0x1111111111111111111111111111111111111111", "1"},
+		{"0x2222222222222222222222222222222222222222", "2"},
+		{"0x1111111111111111111111111111111111111111", "1"},
+	}
+	tree, err := NewStandardMerkleTree(values, []string{"address", "uint256"})
+	if err != nil {
+		t.Fatalf("Failed to build standard tree: %v", err)
+	}
+
+	// The JS library's hashLookup keeps the last occurrence of a value
+	if index, err := tree.LeafLookup(values[0]); err != nil || index != 2 {
+		t.Errorf("Expected the last occurrence at index 2, got %d (%v)", index, err)
+	}
+}
+
+func TestStandardMerkleTreeDumpAndLoad(t *testing.T) {
+	tree, err := NewStandardMerkleTree(standardTestValues, []string{"address", "uint256"})
+	if err != nil {
+		t.Fatalf("Failed to build standard tree: %v", err)
+	}
+
+	encoded, err := json.Marshal(tree.Dump())
+	if err != nil {
+		t.Fatalf("Failed to marshal dump: %v", err)
+	}
+	if !strings.HasPrefix(string(encoded), `{"format":"standard-v1","leafEncoding":["address","uint256"],"tree":["0x`) {
+		t.Errorf("Unexpected dump layout: %s", encoded)
+	}
+
+	var data StandardMerkleTreeData
+	if err := json.Unmarshal(encoded, &data); err != nil {
+		t.Fatalf("Failed to unmarshal dump: %v", err)
+	}
+	loaded, err := LoadStandardMerkleTree(data)
+	if err != nil {
+		t.Fatalf("Failed to load dump: %v", err)
+	}
+	if loaded.GenerateRoot() != tree.GenerateRoot() {
+		t.Error("Loaded tree root should match the original")
+	}
+
+	// Tampering with a leaf must be detected
+	data.Tree[len(data.Tree)-1] = HashData([]byte("tampered"))
+	if _, err := LoadStandardMerkleTree(data); err == nil {
+		t.Error("Expected error loading a tampered tree")
+	}
+
+	data.Format = "simple-v1"
+	if _, err := LoadStandardMerkleTree(data); err == nil {
+		t.Error("Expected error for unknown format")
+	}
+}
+
+func TestStandardLeafHashValidation(t *testing.T) {
+	testCases := []struct {
+		name     string
+		encoding []string
+		value    []interface{}
+	}{
+		{"Wrong value count", []string{"address", "uint256"}, []interface{}{"0x1111111111111111111111111111111111111111"}},
+		{"Invalid address", []string{"address"}, []interface{}{"0x1234"}},
+		{"Negative uint", []string{"uint256"}, []interface{}{"-1"}},
+		{"Overflowing uint8", []string{"uint8"}, []interface{}{"256"}},
+		{"Short bytes32", []string{"bytes32"}, []interface{}{"0x1234"}},
+		{"Unknown type", []string{"uint257"}, []interface{}{"1"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := StandardLeafHash(tc.encoding, tc.value); err == nil {
+				t.Errorf("Expected error for %s", tc.name)
+			}
+		})
+	}
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// Values accepted by the global --output flag
+const (
+	outputText = "text"
+	outputJSON = "json"
+)
+
+// outputFormat holds the global --output flag
+var outputFormat = outputText
+
+// Exit codes, one per failure class, so scripts can tell failures apart without parsing messages
+const (
+	exitUsage        = 2 // unknown command or flag, wrong number of arguments
+	exitInput        = 3 // leaves, values or input files that cannot be read or parsed
+	exitTree         = 4 // the tree could not be built or the target is not in it
+	exitProofInvalid = 5 // verify ran but the proof does not match the root
+	exitOutput       = 6 // results or artifacts could not be written
+	exitServer       = 7 // serve could not listen or stopped with an error
+)
+
+// errorClass groups failures that share an exit code and a JSON error code
+type errorClass struct {
+	code     string
+	exitCode int
+}
+
+var (
+	classUsage        = errorClass{"usage", exitUsage}
+	classInput        = errorClass{"invalid_input", exitInput}
+	classTree         = errorClass{"tree", exitTree}
+	classProofInvalid = errorClass{"proof_invalid", exitProofInvalid}
+	classOutput       = errorClass{"output", exitOutput}
+	classServer       = errorClass{"server", exitServer}
+)
+
+// cliError is a failed subcommand
+type cliError struct {
+	class errorClass
+	// context describes the failed step, e.g. "parsing leaves"
+	context string
+	err     error
+	// result and text are set when the command still has a result to report, like an invalid proof
+	result interface{}
+	text   func(w io.Writer)
+}
+
+func (e *cliError) Error() string {
+	if e.context == "" {
+		return e.err.Error()
+	}
+	return fmt.Sprintf("%s: %v", e.context, e.err)
+}
+
+func (e *cliError) Unwrap() error {
+	return e.err
+}
+
+// fail wraps an error with its failure class and the step that failed
+func fail(class errorClass, context string, err error) error {
+	return &cliError{class: class, context: context, err: err}
+}
+
+// envelope is the JSON document printed for every command with --output json
+type envelope struct {
+	OK      bool        `json:"ok"`
+	Command string      `json:"command"`
+	Result  interface{} `json:"result,omitempty"`
+	Error   *errorBody  `json:"error,omitempty"`
+}
+
+// errorBody describes a failure in the JSON envelope
+type errorBody struct {
+	Code     string `json:"code"`
+	ExitCode int    `json:"exitCode"`
+	Message  string `json:"message"`
+}
+
+// outputFormatFromArgs finds --output before cobra parses the flags, so that errors from
+// parsing itself (unknown commands or flags) are reported in the requested format
+func outputFormatFromArgs(args []string) string {
+	for i, arg := range args {
+		switch {
+		case arg == "--":
+			return outputText
+		case arg == "--output" && i+1 < len(args):
+			return args[i+1]
+		case strings.HasPrefix(arg, "--output="):
+			return strings.TrimPrefix(arg, "--output=")
+		}
+	}
+	return outputText
+}
+
+// validateOutputFormat rejects unknown --output values before any command runs
+func validateOutputFormat(cmd *cobra.Command, args []string) error {
+	if outputFormat != outputText && outputFormat != outputJSON {
+		err := fmt.Errorf("unknown output format %q (expected text or json)", outputFormat)
+		// Report the error itself as text, since the requested format is unusable
+		outputFormat = outputText
+		return fail(classUsage, "", err)
+	}
+	return nil
+}
+
+// writeResult prints a command's result to stdout, see writeResultTo
+func writeResult(cmd *cobra.Command, result interface{}, text func(w io.Writer)) error {
+	return writeResultTo(os.Stdout, cmd, result, text)
+}
+
+// writeResultTo prints a command's result with the text renderer, or as a JSON envelope with --output json
+func writeResultTo(w io.Writer, cmd *cobra.Command, result interface{}, text func(w io.Writer)) error {
+	if outputFormat != outputJSON {
+		text(w)
+		return nil
+	}
+	if err := writeEnvelope(w, envelope{OK: true, Command: cmd.Name(), Result: result}); err != nil {
+		return fail(classOutput, "writing output", err)
+	}
+	return nil
+}
+
+// writeJSON prints a value as indented JSON
+func writeJSON(w io.Writer, value interface{}) {
+	jsonOutput, _ := json.MarshalIndent(value, "", "  ")
+	fmt.Fprintln(w, string(jsonOutput))
+}
+
+func writeEnvelope(w io.Writer, env envelope) error {
+	jsonOutput, err := json.MarshalIndent(env, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(jsonOutput))
+	return err
+}
+
+// reportError prints a failed command in the selected output format and returns its exit code
+// Errors that are not a cliError come from cobra itself (unknown flags, missing arguments) and count as usage errors.
+func reportError(cmd *cobra.Command, err error) int {
+	var ce *cliError
+	if !errors.As(err, &ce) {
+		ce = &cliError{class: classUsage, err: err}
+	}
+
+	if outputFormat == outputJSON {
+		writeEnvelope(os.Stdout, envelope{
+			OK:      false,
+			Command: cmd.Name(),
+			Result:  ce.result,
+			Error: &errorBody{
+				Code:     ce.class.code,
+				ExitCode: ce.class.exitCode,
+				Message:  ce.Error(),
+			},
+		})
+		return ce.class.exitCode
+	}
+
+	switch {
+	case ce.text != nil:
+		ce.text(os.Stdout)
+	case ce.context != "":
+		fmt.Printf("Error %s\n", ce.Error())
+	default:
+		fmt.Printf("Error: %s\n", ce.Error())
+	}
+	if ce.class == classUsage {
+		fmt.Printf("Run '%s --help' for usage.\n", cmd.CommandPath())
+	}
+	return ce.class.exitCode
+}
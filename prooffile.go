@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+
+	"merkle-generator/merkle"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// proofFile is a proof read by verify --proof-file. It accepts the output of the proof command,
+// with or without the --output json envelope, and per-recipient entries such as those in a
+// distribution artifact, whose leaf is recomputed from the address and amount.
+type proofFile struct {
+	Root      *common.Hash    `json:"root"`
+	Target    *common.Hash    `json:"target"`
+	Leaf      *common.Hash    `json:"leaf"`
+	Address   *common.Address `json:"address"`
+	Amount    json.Number     `json:"amount"`
+	Proof     []common.Hash   `json:"proof"`
+	LeafIndex *int            `json:"leafIndex"`
+	LeafCount *int            `json:"leafCount"`
+	// Index and EntryCount are how artifact and shard entries, and serve's /proof, name the position
+	Index      *int `json:"index"`
+	EntryCount *int `json:"entryCount"`
+
+	// Result holds the proof when the file is a --output json envelope
+	Result json.RawMessage `json:"result"`
+}
+
+// readProofFile reads a proof file, or stdin for "-"
+func readProofFile(path string) (*proofFile, error) {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open proof file: %w", err)
+		}
+		defer file.Close()
+		r = file
+	}
+
+	var pf proofFile
+	if err := json.NewDecoder(bufio.NewReader(r)).Decode(&pf); err != nil {
+		return nil, fmt.Errorf("invalid proof file: %w", err)
+	}
+
+	if len(pf.Result) > 0 {
+		var inner proofFile
+		if err := json.Unmarshal(pf.Result, &inner); err != nil {
+			return nil, fmt.Errorf("invalid proof file result: %w", err)
+		}
+		pf = inner
+	}
+
+	if pf.Proof == nil {
+		return nil, errors.New("invalid proof file: missing proof")
+	}
+	if pf.LeafIndex == nil {
+		pf.LeafIndex = pf.Index
+	}
+	if pf.LeafCount == nil {
+		pf.LeafCount = pf.EntryCount
+	}
+	return &pf, nil
+}
+
+// target returns the leaf to verify
+// For per-recipient entries the leaf is keccak256(abi.encodePacked(address, amount)), and any
+// leaf or target in the file must match it.
+func (pf *proofFile) target() (common.Hash, error) {
+	stated := pf.Target
+	if stated == nil {
+		stated = pf.Leaf
+	}
+
+	if pf.Address == nil {
+		if pf.Amount != "" {
+			return common.Hash{}, errors.New("proof file has an amount but no address")
+		}
+		if stated == nil {
+			return common.Hash{}, errors.New("proof file has no target, leaf or address and amount")
+		}
+		return *stated, nil
+	}
+
+	if pf.Amount == "" {
+		return common.Hash{}, errors.New("proof file has an address but no amount")
+	}
+	amount, ok := new(big.Int).SetString(pf.Amount.String(), 10)
+	if !ok || amount.Sign() < 0 {
+		return common.Hash{}, fmt.Errorf("invalid amount %s in proof file", pf.Amount)
+	}
+
+	leaf := merkle.HashAddressAmount(*pf.Address, amount)
+	if stated != nil && *stated != leaf {
+		return common.Hash{}, fmt.Errorf("leaf %s in proof file does not match address %s and amount %s (expected %s)",
+			stated.Hex(), pf.Address.Hex(), amount, leaf.Hex())
+	}
+	return leaf, nil
+}
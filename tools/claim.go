@@ -11,22 +11,39 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"log"
+	"math/big"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"text/tabwriter"
+	"time"
 
 	"merkle-generator/merkle"
 	"merkle-generator/util"
 
+	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
 )
 
 // Command line flags
 var (
-	configFile = flag.String("config", "examples/config.yml", "Path to configuration file")
-	targetAddr = flag.String("address", "", "Target address to claim for (optional - claims for all addresses if not specified)")
-	dryRun     = flag.Bool("dry-run", false, "Generate proof and prepare transaction but don't send it")
-	help       = flag.Bool("help", false, "Show help message")
+	configFile  = flag.String("config", "examples/config.yml", "Path to configuration file")
+	targetAddr  = flag.String("address", "", "Target address to claim for (optional - claims for all addresses if not specified)")
+	dryRun      = flag.Bool("dry-run", false, "Generate proof and prepare transaction but don't send it")
+	journalFile = flag.String("journal", "claims.journal.jsonl", "Journal file recording each address's claim state")
+	concurrency = flag.Int("concurrency", 1, "Number of claims to process at once; one sender's claims always go out in order")
+	resume      = flag.Bool("resume", false, "Resume from the journal: skip confirmed claims, check pending ones and retry failed ones")
+	skipChecks  = flag.Bool("skip-onchain-checks", false, "Don't compare the contract's merkle root or ask it which addresses already claimed")
+	help        = flag.Bool("help", false, "Show help message")
 )
 
 func main() {
@@ -51,12 +68,12 @@ func main() {
 		fmt.Println("   Please update config file with your settings:")
 		fmt.Println("   - rpc.endpoint: Your Ethereum RPC URL")
 		fmt.Println("   - rpc.contract_address: Your TokenClaimer contract address")
-		fmt.Println("   - rpc.private_key: Your private key for transactions")
+		fmt.Println("   - signer: key_file, key_env, keystore_dir, keystore_file or mnemonic_file with the claimers' keys")
 		return
 	}
 
 	// Connect to Ethereum node
-	client, err := util.NewEthClient(config.RPC.Endpoint)
+	client, err := util.NewEthClientWithOptions(config.RPC.Endpoint, util.ClientOptions{RequestsPerSecond: config.RPC.RequestsPerSecond})
 	if err != nil {
 		log.Fatalf("Failed to connect to Ethereum node: %v", err)
 	}
@@ -64,6 +81,13 @@ func main() {
 
 	fmt.Printf("Connected to chain ID: %s\n", client.ChainID.String())
 
+	client.Fees, err = config.Fees.Options()
+	if err != nil {
+		log.Fatalf("Failed to read fee settings: %v", err)
+	}
+	// Track nonces locally so one sender can have several claims in flight
+	client.Nonces = util.NewNonceManager(client)
+
 	// Create contract wrapper
 	contract, err := util.NewTokenClaimerContract(config.RPC.ContractAddress, client)
 	if err != nil {
@@ -102,109 +126,522 @@ func showHelp() {
 	fmt.Println()
 	fmt.Println("  # Claim for specific address")
 	fmt.Println("  go run tools/claim.go -config examples/config.yml -address 0x123...")
+	fmt.Println()
+	fmt.Println("  # Pick up a crashed or interrupted run where it stopped")
+	fmt.Println("  go run tools/claim.go -config examples/config.yml -resume")
 }
 
 func executeClaims(contract *util.TokenClaimerContract, config *util.Config) error {
-	// Read claimer information from CSV file
-	fmt.Printf("Reading claimer data from CSV: %s\n", config.CSV.FilePath)
-	claimers, err := util.ReadClaimersFromCSV(config.CSV.FilePath)
+	// Read the allocation list, the same address,amount format csv_merkle_generator reads
+	fmt.Printf("Reading allocations from CSV: %s\n", config.CSV.FilePath)
+	csvOpts, err := config.CSV.Options(contract.Client)
 	if err != nil {
-		return fmt.Errorf("failed to read claimers from CSV: %v", err)
+		return err
 	}
-	fmt.Printf("Loaded %d claimers from CSV\n", len(claimers))
+	testCases, report, err := util.ReadAllocations(config.CSV.FilePath, csvOpts)
+	if err != nil {
+		return fmt.Errorf("failed to read allocations from CSV: %v", err)
+	}
+	report.Duplicates.Write(os.Stdout)
+	fmt.Printf("Loaded %d allocations from CSV\n", len(testCases))
 
-	// Filter claimers based on target address if specified
-	var targetClaimers []util.ClaimerInfo
+	// Keys come from a separate source, only needed when transactions are sent
+	var signers *util.SignerSet
+	if !*dryRun {
+		signers, err = config.Signer.Signers()
+		if err != nil {
+			return fmt.Errorf("failed to open signer keys: %v", err)
+		}
+	}
+
+	// Filter allocations based on target address if specified
+	var targets []util.TestCase
 	if *targetAddr != "" {
 		targetAddress := common.HexToAddress(*targetAddr)
-		for _, claimer := range claimers {
-			if claimer.Address == targetAddress {
-				targetClaimers = append(targetClaimers, claimer)
+		for _, testCase := range testCases {
+			if testCase.Address == targetAddress {
+				targets = append(targets, testCase)
 				break
 			}
 		}
-		if len(targetClaimers) == 0 {
+		if len(targets) == 0 {
 			return fmt.Errorf("address %s not found in CSV", targetAddress.Hex())
 		}
 	} else {
 		// Claim for all addresses in CSV
-		targetClaimers = claimers
+		targets = testCases
 	}
 
-	fmt.Printf("Will process %d claim(s)\n", len(targetClaimers))
+	fmt.Printf("Will process %d claim(s)\n", len(targets))
 
-	// Generate merkle tree data from all claimers
+	// Generate merkle tree data from all allocations
 	fmt.Println("\n=== Generating Merkle Tree ===")
-	testCases, err := util.ReadCSVTestCases(config.CSV.FilePath)
+
+	leafEncoder, err := util.NewLeafEncoder(config.Leaf)
 	if err != nil {
-		return fmt.Errorf("failed to read test cases: %v", err)
+		return fmt.Errorf("invalid leaf configuration: %v", err)
 	}
 
-	merkleData, err := util.GenerateLocalMerkleData(testCases)
+	merkleData, err := util.GenerateLocalMerkleDataWithEncoder(testCases, leafEncoder)
 	if err != nil {
 		return fmt.Errorf("failed to generate merkle data: %v", err)
 	}
+	fmt.Printf("Leaf: %s\n", leafEncoder.String())
 	fmt.Printf("Merkle Root: %s\n", merkleData.Root.Hex())
 
-	// Process each claim individually
-	for i, claimer := range targetClaimers {
-		fmt.Printf("\n=== Processing Claim %d/%d ===\n", i+1, len(targetClaimers))
-		fmt.Printf("Claimer: %s (%s)\n", claimer.Name, claimer.Address.Hex())
-		fmt.Printf("Amount: %s\n", claimer.Amount.String())
-
-		// Find the index for this claimer in the test cases
-		targetIndex := util.FindTestCaseIndex(testCases, claimer.Address)
-		if targetIndex == -1 {
-			fmt.Printf("⚠️  Skipping %s - not found in merkle tree data\n", claimer.Address.Hex())
-			continue
+	// Claims against a different root would all revert, so refuse to start
+	if !*skipChecks {
+		if err := contract.CheckMerkleRoot(merkleData.Root); err != nil {
+			return err
 		}
+		fmt.Println("✅ Merkle root matches the contract")
+		checkTokenBalance(contract, targets)
+	}
+
+	receiptOpts, err := config.Receipts.Options()
+	if err != nil {
+		return err
+	}
+
+	run := &claimRun{
+		contract:    contract,
+		signers:     signers,
+		testCases:   testCases,
+		merkleData:  merkleData,
+		leafEncoder: leafEncoder,
+		receiptOpts: receiptOpts,
+	}
 
-		// Generate proof for this claimer
-		proof, err := merkleData.GenerateLocalProof(targetIndex)
+	// Record every claim's state so a crashed run can be resumed without resending
+	if !*dryRun {
+		run.journal, err = util.OpenClaimJournal(*journalFile, *resume)
 		if err != nil {
-			fmt.Printf("❌ Failed to generate proof for %s: %v\n", claimer.Address.Hex(), err)
-			continue
+			return err
+		}
+		defer run.journal.Close()
+		if *resume {
+			fmt.Printf("Resuming from journal %s with %d recorded address(es)\n", *journalFile, run.journal.Entries())
 		}
+	}
 
-		// Verify proof locally
-		targetLeaf := merkle.HashAddressAmount(claimer.Address, claimer.Amount)
-		isValid := merkle.VerifyProof(proof, merkleData.Root, targetLeaf)
-		if !isValid {
-			fmt.Printf("❌ Invalid proof for %s\n", claimer.Address.Hex())
-			continue
+	// Ctrl-C stops new claims from being sent while claims already sent wait for their receipts;
+	// a second Ctrl-C stops those waits too
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	waitCtx, stopWaits := context.WithCancel(context.Background())
+	defer stopWaits()
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(interrupts)
+	go func() {
+		if _, ok := <-interrupts; !ok {
+			return
 		}
-		fmt.Printf("✅ Proof verification successful\n")
+		fmt.Println("\n⏹  Interrupted - finishing claims in flight, press Ctrl-C again to quit now")
+		cancel()
+		if _, ok := <-interrupts; !ok {
+			return
+		}
+		// Restore the default handling, so a third Ctrl-C kills the process
+		signal.Stop(interrupts)
+		fmt.Println("\n⏹  Interrupted again - no longer waiting for receipts")
+		stopWaits()
+	}()
+	run.ctx, run.waitCtx = ctx, waitCtx
+
+	results := run.processClaims(cancel, targets)
+	if run.err != nil {
+		printClaimSummary(results)
+		return run.err
+	}
 
-		if *dryRun {
-			fmt.Printf("🏃 Dry run - would claim %s for %s\n", claimer.Amount.String(), claimer.Address.Hex())
-			continue
+	fmt.Println("\n=== Claims Complete ===")
+	printClaimSummary(results)
+	return nil
+}
+
+// checkTokenBalance warns when the contract holds fewer tokens than the targets are owed. Contracts
+// without a token() view are not checked.
+func checkTokenBalance(contract *util.TokenClaimerContract, targets []util.TestCase) {
+	token, balance, err := contract.TokenBalance()
+	if err != nil {
+		fmt.Printf("⚠️  Could not check the contract's token balance: %v\n", err)
+		return
+	}
+
+	owed := new(big.Int)
+	for _, target := range targets {
+		owed.Add(owed, target.Amount)
+	}
+	fmt.Printf("Contract balance of token %s: %s (targets are owed %s)\n", token.Hex(), balance.String(), owed.String())
+	if balance.Cmp(owed) < 0 {
+		fmt.Println("⚠️  The contract holds less than the targets are owed; later claims may revert unless earlier ones were already paid")
+	}
+}
+
+// processClaims runs the claims on a pool of -concurrency workers. All claims of one sender go to
+// the same worker in order, so its nonces are used in sequence. It returns one result per target;
+// claims that never started because of an interrupt or run.err are marked skipped.
+func (r *claimRun) processClaims(cancel context.CancelFunc, targets []util.TestCase) []claimResult {
+	results := make([]claimResult, len(targets))
+	started := make([]bool, len(targets))
+
+	// Claims are sent by the claimer's own key, so the claimer is the sender
+	var senders []common.Address
+	bySender := make(map[common.Address][]int)
+	for i, claimer := range targets {
+		if _, ok := bySender[claimer.Address]; !ok {
+			senders = append(senders, claimer.Address)
+		}
+		bySender[claimer.Address] = append(bySender[claimer.Address], i)
+	}
+
+	workers := *concurrency
+	if workers < 1 {
+		workers = 1
+	}
+	jobs := make(chan []int)
+	var outputMu, errMu sync.Mutex
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for indexes := range jobs {
+				for _, i := range indexes {
+					if r.ctx.Err() != nil {
+						break
+					}
+					started[i] = true
+
+					// A single worker prints as it goes; several buffer each claim to keep it together
+					var out io.Writer = os.Stdout
+					buffer := new(bytes.Buffer)
+					if workers > 1 {
+						out = buffer
+					}
+					result, err := r.processClaim(targets[i], i, len(targets), out)
+					results[i] = result
+					if workers > 1 {
+						outputMu.Lock()
+						os.Stdout.Write(buffer.Bytes())
+						outputMu.Unlock()
+					}
+
+					if err != nil {
+						errMu.Lock()
+						if r.err == nil {
+							r.err = err
+						}
+						errMu.Unlock()
+						cancel()
+					}
+				}
+			}
+		}()
+	}
+
+dispatch:
+	for _, sender := range senders {
+		select {
+		case <-r.ctx.Done():
+			break dispatch
+		case jobs <- bySender[sender]:
 		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	for i, claimer := range targets {
+		if !started[i] {
+			results[i] = claimResult{Address: claimer.Address, Outcome: outcomeSkipped, Detail: "not started, the run was stopped"}
+		}
+	}
+	return results
+}
+
+// Claim outcomes, as shown in the summary table
+const (
+	outcomeSucceeded   = "succeeded"
+	outcomeReverted    = "reverted"
+	outcomeTimedOut    = "timed out"
+	outcomeFailed      = "failed"
+	outcomeSkipped     = "skipped"
+	outcomeInterrupted = "interrupted"
+)
+
+// claimResult is one row of the summary table
+type claimResult struct {
+	Address common.Address
+	Outcome string
+	TxHash  common.Hash
+	Detail  string
+}
+
+// claimRun holds what every claim in a run shares
+type claimRun struct {
+	contract    *util.TokenClaimerContract
+	signers     *util.SignerSet
+	testCases   []util.TestCase
+	merkleData  *util.MerkleData
+	leafEncoder *util.LeafEncoder
+	receiptOpts util.ReceiptOptions
+	// journal is nil in dry runs
+	journal *util.ClaimJournal
+	// ctx is canceled when the run is interrupted, stopping new claims from being sent
+	ctx context.Context
+	// waitCtx is canceled by a second interrupt, stopping the waits for receipts
+	waitCtx context.Context
+	// err is the first error that stopped the run
+	err error
+}
+
+// processClaim proves, sends and waits for one claim, picking up where an earlier run left off
+// Only a failure to write the journal is returned as an error; it stops the run.
+func (r *claimRun) processClaim(claimer util.TestCase, index, total int, out io.Writer) (result claimResult, err error) {
+	fmt.Fprintf(out, "\n=== Processing Claim %d/%d ===\n", index+1, total)
+	fmt.Fprintf(out, "Claimer: %s (%s)\n", claimer.Name, claimer.Address.Hex())
+	fmt.Fprintf(out, "Amount: %s\n", claimer.Amount.String())
+	defer func() {
+		if err != nil && result.Outcome == "" {
+			result.Outcome, result.Detail = outcomeFailed, err.Error()
+		}
+		switch result.Outcome {
+		case outcomeSucceeded:
+			fmt.Fprintf(out, "✅ Successfully claimed for %s\n", claimer.Address.Hex())
+		case outcomeSkipped:
+			fmt.Fprintf(out, "⚠️  Skipping %s - %s\n", claimer.Address.Hex(), result.Detail)
+		default:
+			fmt.Fprintf(out, "❌ Claim %s for %s: %s\n", result.Outcome, claimer.Address.Hex(), result.Detail)
+		}
+	}()
+
+	result = claimResult{Address: claimer.Address}
 
-		// Execute the claim
-		err = executeSingleClaim(contract, claimer, proof, merkleData.Root)
+	if r.journal != nil {
+		if entry, ok := r.journal.Latest(claimer.Address); ok {
+			if entry.TxHash != nil {
+				result.TxHash = *entry.TxHash
+			}
+			switch entry.State {
+			case util.ClaimConfirmed:
+				result.Outcome, result.Detail = outcomeSkipped, "confirmed in an earlier run"
+				return result, nil
+			case util.ClaimReverted:
+				result.Outcome, result.Detail = outcomeSkipped, "reverted in an earlier run: "+entry.Detail
+				return result, nil
+			case util.ClaimPending:
+				if done, err := r.reconcile(&result, out); done || err != nil {
+					return result, err
+				}
+			case util.ClaimFailed:
+				fmt.Fprintf(out, "  Retrying after an earlier failure: %s\n", entry.Detail)
+			}
+			result.TxHash = common.Hash{}
+		}
+	}
+
+	// Find the index for this claimer in the test cases
+	targetIndex := util.FindTestCaseIndex(r.testCases, claimer.Address)
+	if targetIndex == -1 {
+		result.Outcome, result.Detail = outcomeSkipped, "not found in merkle tree data"
+		return result, nil
+	}
+
+	// Generate proof for this claimer
+	proof, err := r.merkleData.GenerateLocalProof(targetIndex)
+	if err != nil {
+		result.Outcome, result.Detail = outcomeSkipped, fmt.Sprintf("failed to generate proof: %v", err)
+		return result, nil
+	}
+
+	// Verify proof locally
+	targetLeaf, err := r.leafEncoder.Hash(targetIndex, r.testCases[targetIndex])
+	if err != nil {
+		result.Outcome, result.Detail = outcomeSkipped, fmt.Sprintf("failed to encode leaf: %v", err)
+		return result, nil
+	}
+	isValid := merkle.VerifyProof(proof, r.merkleData.Root, targetLeaf)
+	if !isValid {
+		result.Outcome, result.Detail = outcomeSkipped, "invalid proof"
+		return result, nil
+	}
+	fmt.Fprintf(out, "✅ Proof verification successful\n")
+
+	// Sending for an address that already claimed would only revert
+	if !*skipChecks {
+		claimed, err := r.contract.HasClaimed(claimer.Address, uint64(targetIndex))
 		if err != nil {
-			fmt.Printf("❌ Failed to claim for %s: %v\n", claimer.Address.Hex(), err)
-			continue
+			result.Outcome, result.Detail = outcomeFailed, fmt.Sprintf("failed to check claimed status: %v", err)
+			return result, r.record(result, util.ClaimFailed)
 		}
+		if claimed {
+			result.Outcome, result.Detail = outcomeSkipped, "already claimed on chain"
+			return result, nil
+		}
+	}
 
-		fmt.Printf("✅ Successfully claimed for %s\n", claimer.Address.Hex())
+	if *dryRun {
+		fmt.Fprintf(out, "🏃 Dry run - would claim %s for %s\n", claimer.Amount.String(), claimer.Address.Hex())
+		result.Outcome, result.Detail = outcomeSkipped, "dry run"
+		return result, nil
 	}
 
-	fmt.Println("\n=== Claims Complete ===")
+	signer, err := r.signers.Signer(claimer.Address)
+	if err != nil {
+		result.Outcome, result.Detail = outcomeSkipped, fmt.Sprintf("no signer key: %v", err)
+		return result, nil
+	}
+
+	// Execute the claim
+	tx, err := r.executeSingleClaim(claimer, signer, proof, out)
+	if tx != nil {
+		result.TxHash = tx.Hash()
+	}
+	if errors.Is(err, errJournal) {
+		return result, err
+	}
+	if errors.Is(err, context.Canceled) {
+		// Interrupted while gas was above the ceiling; nothing was sent
+		result.Outcome, result.Detail = outcomeSkipped, "gas price above the ceiling when the run was stopped"
+		return result, nil
+	}
+	if err != nil {
+		result.Outcome, result.Detail = outcomeFailed, err.Error()
+		return result, r.record(result, util.ClaimFailed)
+	}
+
+	return result, r.await(tx, &result, out)
+}
+
+// reconcile settles a claim left pending by an earlier run. It returns false when the transaction
+// never reached the node, so the claim should be sent again.
+func (r *claimRun) reconcile(result *claimResult, out io.Writer) (bool, error) {
+	fmt.Fprintf(out, "  Checking transaction %s from an earlier run\n", result.TxHash.Hex())
+	tx, _, err := r.contract.Client.TransactionByHash(context.Background(), result.TxHash)
+	switch {
+	case errors.Is(err, ethereum.NotFound):
+		fmt.Fprintf(out, "  Transaction %s is unknown to the node, sending the claim again\n", result.TxHash.Hex())
+		return false, nil
+	case err != nil:
+		// Resending without knowing could claim twice, so leave it pending for the next resume
+		result.Outcome, result.Detail = outcomeFailed, fmt.Sprintf("could not check earlier transaction: %v", err)
+		return true, nil
+	}
+	return true, r.await(tx, result, out)
+}
+
+// await waits for a sent claim and records how it ended; a timed out or interrupted claim stays pending
+func (r *claimRun) await(tx *types.Transaction, result *claimResult, out io.Writer) error {
+	fmt.Fprintf(out, "  Waiting for %d confirmation(s)...\n", max(r.receiptOpts.Confirmations, util.DefaultConfirmations))
+	receipt, err := r.contract.Client.WaitForReceipt(r.waitCtx, tx.Hash(), r.receiptOpts)
+	switch {
+	case errors.Is(err, util.ErrReceiptTimeout):
+		result.Outcome, result.Detail = outcomeTimedOut, err.Error()
+		return nil
+	case errors.Is(err, context.Canceled):
+		result.Outcome, result.Detail = outcomeInterrupted, "sent but not confirmed, -resume checks it"
+		return nil
+	case err != nil:
+		result.Outcome, result.Detail = outcomeFailed, err.Error()
+		return nil
+	case receipt.Status == types.ReceiptStatusSuccessful:
+		result.Outcome, result.Detail = outcomeSucceeded, fmt.Sprintf("block %s, gas used %d", receipt.BlockNumber, receipt.GasUsed)
+		return r.record(*result, util.ClaimConfirmed)
+	default:
+		result.Outcome = outcomeReverted
+		reason, err := r.contract.RevertReason(context.Background(), tx, receipt)
+		if err != nil {
+			reason = fmt.Sprintf("reason unavailable: %v", err)
+		}
+		result.Detail = reason
+		return r.record(*result, util.ClaimReverted)
+	}
+}
+
+// errJournal marks journal write failures, which stop the run since it could no longer be resumed safely
+var errJournal = errors.New("stopping, claims can't be resumed safely")
+
+// record writes a claim's state to the journal
+func (r *claimRun) record(result claimResult, state string) error {
+	if r.journal == nil {
+		// Dry runs keep no journal
+		return nil
+	}
+	entry := util.JournalEntry{Address: result.Address, State: state, Detail: result.Detail}
+	if result.TxHash != (common.Hash{}) {
+		entry.TxHash = &result.TxHash
+	}
+	if err := r.journal.Record(entry); err != nil {
+		return fmt.Errorf("%w: %v", errJournal, err)
+	}
 	return nil
 }
 
-func executeSingleClaim(contract *util.TokenClaimerContract, claimer util.ClaimerInfo, proof []common.Hash, merkleRoot common.Hash) error {
+// printClaimSummary prints one row per claimer and the count of each outcome
+func printClaimSummary(results []claimResult) {
+	fmt.Println("\n=== Claim Summary ===")
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ADDRESS\tOUTCOME\tTRANSACTION\tDETAIL")
+	counts := make(map[string]int)
+	for _, result := range results {
+		txHash := "-"
+		if result.TxHash != (common.Hash{}) {
+			txHash = result.TxHash.Hex()
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", result.Address.Hex(), result.Outcome, txHash, result.Detail)
+		counts[result.Outcome]++
+	}
+	w.Flush()
+
+	fmt.Printf("\n%d succeeded, %d reverted, %d timed out, %d interrupted, %d failed, %d skipped\n",
+		counts[outcomeSucceeded], counts[outcomeReverted], counts[outcomeTimedOut], counts[outcomeInterrupted], counts[outcomeFailed], counts[outcomeSkipped])
+}
+
+func (r *claimRun) executeSingleClaim(claimer util.TestCase, signer util.Signer, proof []common.Hash, out io.Writer) (*types.Transaction, error) {
 	// Prepare claim transaction
-	data, err := contract.PrepareClaimTransaction(claimer.Address, claimer.Amount, proof)
+	data, err := r.contract.PrepareClaimTransaction(claimer.Address, claimer.Amount, proof)
 	if err != nil {
-		return fmt.Errorf("failed to prepare claim transaction: %v", err)
+		return nil, fmt.Errorf("failed to prepare claim transaction: %v", err)
 	}
 
+	// A nonce taken by a transaction sent elsewhere resyncs the sender; try again with a fresh one
+	for attempt := 1; ; attempt++ {
+		tx, err := r.sendBelowCeiling(claimer, signer, data, out)
+		if util.IsNonceTooLow(err) && attempt < maxNonceAttempts {
+			fmt.Fprintf(out, "  Nonce already used, retrying with a fresh nonce\n")
+			continue
+		}
+		return tx, err
+	}
+}
+
+// maxNonceAttempts bounds how often a claim is resent after "nonce too low"
+const maxNonceAttempts = 3
+
+// sendBelowCeiling sends a claim once gas is no more expensive than the configured ceiling,
+// checking the fees again with backoff until then or until the run is interrupted
+func (r *claimRun) sendBelowCeiling(claimer util.TestCase, signer util.Signer, data []byte, out io.Writer) (*types.Transaction, error) {
+	var tx *types.Transaction
+	err := util.WaitBelowCeiling(r.ctx, util.CeilingBackoff{}, func() error {
+		var err error
+		tx, err = r.sendClaimTransaction(claimer, signer, data, out)
+		return err
+	}, func(err error, delay time.Duration) {
+		fmt.Fprintf(out, "  ⏳ %v, checking again in %s\n", err, delay)
+	})
+	return tx, err
+}
+
+// sendClaimTransaction signs a claim, journals it as pending and sends it
+// A failed send returns the signed transaction along with the error.
+func (r *claimRun) sendClaimTransaction(claimer util.TestCase, signer util.Signer, data []byte, out io.Writer) (*types.Transaction, error) {
+	contract := r.contract
+
 	// Get account info for this claimer
-	account, err := contract.Client.GetAccountInfo(claimer.PrivateKey)
+	account, err := contract.Client.GetAccountInfoForSigner(signer)
 	if err != nil {
-		return fmt.Errorf("failed to get account info for claimer: %v", err)
+		return nil, fmt.Errorf("failed to get account info for claimer: %v", err)
 	}
 
 	// Create transaction parameters
@@ -216,23 +653,37 @@ func executeSingleClaim(contract *util.TokenClaimerContract, claimer util.Claime
 	// Create transaction
 	tx, err := contract.Client.EstimateAndCreateTransaction(account, txParams)
 	if err != nil {
-		return fmt.Errorf("failed to create transaction: %v", err)
+		contract.Client.ReleaseNonce(account.Address, account.Nonce)
+		return nil, fmt.Errorf("failed to create transaction: %w", err)
 	}
 
-	fmt.Printf("  Transaction details:\n")
-	fmt.Printf("    From: %s\n", account.Address.Hex())
-	fmt.Printf("    To (contract): %s\n", contract.Address.Hex())
-	fmt.Printf("    Gas Limit: %d\n", tx.Gas())
-	fmt.Printf("    Gas Price: %s wei\n", tx.GasPrice().String())
-	fmt.Printf("    Nonce: %d\n", tx.Nonce())
+	fmt.Fprintf(out, "  Transaction details:\n")
+	fmt.Fprintf(out, "    From: %s\n", account.Address.Hex())
+	fmt.Fprintf(out, "    To (contract): %s\n", contract.Address.Hex())
+	fmt.Fprintf(out, "    Gas Limit: %d\n", tx.Gas())
+	if tx.Type() == types.DynamicFeeTxType {
+		fmt.Fprintf(out, "    Max Fee: %s wei\n", tx.GasFeeCap().String())
+		fmt.Fprintf(out, "    Max Priority Fee: %s wei\n", tx.GasTipCap().String())
+	} else {
+		fmt.Fprintf(out, "    Gas Price: %s wei\n", tx.GasPrice().String())
+	}
+	fmt.Fprintf(out, "    Nonce: %d\n", tx.Nonce())
 
-	// Sign and send transaction
-	signedTx, err := contract.Client.SignAndSendTransaction(tx, account.PrivateKey)
+	// Sign, then journal the hash before sending, so a crash right after sending is never resent blindly
+	signedTx, err := contract.Client.SignTransaction(tx, account.Signer)
 	if err != nil {
-		return fmt.Errorf("failed to sign and send transaction: %v", err)
+		return nil, err
+	}
+	if err := r.record(claimResult{Address: claimer.Address, TxHash: signedTx.Hash()}, util.ClaimPending); err != nil {
+		contract.Client.ReleaseNonce(account.Address, account.Nonce)
+		return nil, err
+	}
+	// "already known" means this very transaction is in the pool already, e.g. from an earlier attempt
+	if err := contract.Client.SendSignedTransaction(signedTx, account.Address); err != nil && !util.IsAlreadyKnown(err) {
+		return signedTx, err
 	}
 
-	fmt.Printf("  Transaction sent: %s\n", signedTx.Hash().Hex())
+	fmt.Fprintf(out, "  Transaction sent: %s\n", signedTx.Hash().Hex())
 
-	return nil
+	return signedTx, nil
 }
@@ -1,44 +1,118 @@
 package main
 
 import (
-	"encoding/csv"
+	"flag"
 	"fmt"
 	"log"
 	"math/big"
 	"os"
+	"path/filepath"
 
 	"merkle-generator/merkle"
+	"merkle-generator/util"
 
 	"github.com/ethereum/go-ethereum/common"
 )
 
+// Command line flags
+var (
+	leafTypes    = flag.String("leaf-types", "", "Solidity types of each leaf value (default address,uint256)")
+	leafFields   = flag.String("leaf-fields", "", "Source of each leaf value: index, address, amount or a CSV header name")
+	leafEncoding = flag.String("leaf-encoding", "packed", "Leaf encoding: packed (abi.encodePacked) or standard (abi.encode)")
+	doubleHash   = flag.Bool("double-hash", false, "Hash the encoded leaf twice, like OpenZeppelin's StandardMerkleTree")
+	shardsDir    = flag.String("shards", "", "Directory to write address-prefix proof shards and their index.json manifest to")
+	shardPrefix  = flag.Int("shard-prefix", util.DefaultShardPrefixLength, "Hex digits of the address used to name shards (2 gives proofs/0x3a.json)")
+	columnMap    = flag.String("column", "", "Header names of the columns, e.g. address=wallet,amount=qty")
+	skipInvalid  = flag.Bool("skip-invalid", false, "Skip and report invalid rows instead of failing")
+	duplicates   = flag.String("duplicates", util.DuplicateError.String(), "What to do with an address on several rows: error, sum, first or last")
+	decimals     = flag.Int("decimals", -1, "Read amounts like 1,234.5 or 1.5e3 in whole tokens with this many decimals")
+	decimalToken = flag.String("decimals-token", "", "ERC-20 token to read the decimals from, instead of -decimals")
+	rpcURL       = flag.String("rpc", "", "RPC endpoint used with -decimals-token")
+)
+
 func main() {
-	if len(os.Args) < 2 || len(os.Args) > 3 {
-		fmt.Println("Usage: go run tools/csv_merkle_generator.go <csv_file> [verbose]")
+	flag.Parse()
+	args := flag.Args()
+
+	if len(args) < 1 || len(args) > 2 {
+		fmt.Println("Usage: go run tools/csv_merkle_generator.go [flags] <csv_file> [verbose]")
 		fmt.Println("Example: go run tools/csv_merkle_generator.go data/airdrop.csv")
 		fmt.Println("Example: go run tools/csv_merkle_generator.go data/airdrop.csv true")
+		fmt.Println("Example: go run tools/csv_merkle_generator.go -leaf-types uint256,address,uint256 -leaf-fields index,address,amount data/airdrop.csv")
+		fmt.Println("Example: go run tools/csv_merkle_generator.go -shards proofs data/airdrop.csv")
+		fmt.Println("Example: go run tools/csv_merkle_generator.go -column address=wallet,amount=qty data/airdrop.csv")
+		fmt.Println("Example: go run tools/csv_merkle_generator.go -decimals 6 data/airdrop.csv")
+		fmt.Println()
+		flag.PrintDefaults()
 		os.Exit(1)
 	}
 
-	csvFile := os.Args[1]
+	csvFile := args[0]
 	verbose := false
-	if len(os.Args) == 3 && os.Args[2] == "true" {
+	if len(args) == 2 && args[1] == "true" {
 		verbose = true
 	}
 
+	leafEncoder, err := util.NewLeafEncoder(util.LeafConfig{
+		Types:      *leafTypes,
+		Fields:     *leafFields,
+		Encoding:   *leafEncoding,
+		DoubleHash: *doubleHash,
+	})
+	if err != nil {
+		log.Fatalf("Error in leaf configuration: %v", err)
+	}
+
+	columns, err := util.ParseColumnMapping(*columnMap)
+	if err != nil {
+		log.Fatalf("Error in -column: %v", err)
+	}
+
+	duplicatePolicy, err := util.DuplicatePolicyByName(*duplicates)
+	if err != nil {
+		log.Fatalf("Error in -duplicates: %v", err)
+	}
+
+	csvOptions := util.CSVOptions{
+		Columns:     columns,
+		SkipInvalid: *skipInvalid,
+		Duplicates:  duplicatePolicy,
+	}
+	if err := readDecimals(&csvOptions); err != nil {
+		log.Fatalf("Error in decimals: %v", err)
+	}
+
 	// Read CSV file
-	addresses, amounts, err := readCSV(csvFile)
+	testCases, report, err := util.ReadAllocations(csvFile, csvOptions)
 	if err != nil {
 		log.Fatalf("Error reading CSV: %v", err)
 	}
+	for _, invalid := range report.Invalid {
+		fmt.Printf("Warning: Skipping %s\n", invalid)
+	}
+	fmt.Printf("Processed %d valid entries out of %d total rows\n", report.ValidRows, report.Rows)
+	report.Duplicates.Write(os.Stdout)
+
+	addresses := make([]common.Address, len(testCases))
+	amounts := make([]*big.Int, len(testCases))
+	for i, testCase := range testCases {
+		addresses[i] = testCase.Address
+		amounts[i] = testCase.Amount
+	}
 
 	fmt.Printf("=== Processing CSV: %s ===\n", csvFile)
-	fmt.Printf("Total entries: %d\n\n", len(addresses))
+	fmt.Printf("Total entries: %d\n", len(addresses))
+	fmt.Printf("Leaf: %s\n\n", leafEncoder.String())
 
-	// Generate leaves from addresses and amounts
-	leaves := make([]common.Hash, len(addresses))
-	for i := range addresses {
-		leaves[i] = merkle.HashAddressAmount(addresses[i], amounts[i])
+	// Generate leaves and the Merkle tree
+	distribution, err := util.BuildDistribution(testCases, leafEncoder, util.DefaultTreeConfig())
+	if err != nil {
+		log.Fatalf("Error creating Merkle tree: %v", err)
+	}
+	leaves := distribution.MerkleData.Leaves
+	tree := distribution.MerkleData.Tree
+
+	for i := range leaves {
 		if verbose || i < 5 || i == len(addresses)-1 {
 			fmt.Printf("Entry %d: %s (amount: %s) -> Leaf: %s\n",
 				i+1, addresses[i].Hex(), amounts[i].String(), leaves[i].Hex())
@@ -47,12 +121,6 @@ func main() {
 		}
 	}
 
-	// Create Merkle tree
-	tree, err := merkle.NewMerkleTree(leaves)
-	if err != nil {
-		log.Fatalf("Error creating Merkle tree: %v", err)
-	}
-
 	// Generate root
 	root := tree.GenerateRoot()
 	fmt.Printf("\n=== Merkle Root ===\n")
@@ -101,6 +169,16 @@ func main() {
 	// Save results to files
 	saveResults(addresses, amounts, leaves, root, csvFile)
 
+	// Export proofs for static hosting
+	if *shardsDir != "" {
+		manifest, err := distribution.ExportShards(*shardsDir, *shardPrefix)
+		if err != nil {
+			log.Fatalf("Error exporting proof shards: %v", err)
+		}
+		fmt.Printf("Proofs for %d entries written to %d shards in: %s\n", manifest.EntryCount, len(manifest.Shards), *shardsDir)
+		fmt.Printf("Shard manifest saved to: %s\n", filepath.Join(*shardsDir, util.ShardManifestFile))
+	}
+
 	// Verify a random entry at the end
 	if len(leaves) > 100 {
 		verifyIndex := 100 // Verify entry 100
@@ -118,59 +196,40 @@ func main() {
 	}
 }
 
-func readCSV(filename string) ([]common.Address, []*big.Int, error) {
-	file, err := os.Open(filename)
-	if err != nil {
-		return nil, nil, err
+// readDecimals sets the decimals from -decimals or from the token given by -decimals-token
+func readDecimals(opts *util.CSVOptions) error {
+	if *decimalToken == "" {
+		if *decimals >= 0 {
+			opts.DecimalAmounts = true
+			opts.Decimals = *decimals
+		}
+		return nil
 	}
-	defer file.Close()
 
-	reader := csv.NewReader(file)
-	records, err := reader.ReadAll()
-	if err != nil {
-		return nil, nil, err
+	if *decimals >= 0 {
+		return fmt.Errorf("use either -decimals or -decimals-token")
 	}
-
-	if len(records) < 2 {
-		return nil, nil, fmt.Errorf("CSV file must have at least a header and one data row")
+	if !common.IsHexAddress(*decimalToken) {
+		return fmt.Errorf("invalid token address %s", *decimalToken)
 	}
-
-	// Skip header row
-	records = records[1:]
-
-	var addresses []common.Address
-	var amounts []*big.Int
-
-	for i, record := range records {
-		if len(record) < 2 {
-			fmt.Printf("Warning: Skipping row %d (insufficient columns)\n", i+2)
-			continue
-		}
-
-		// Parse address
-		address := common.HexToAddress(record[0])
-		if address == (common.Address{}) {
-			fmt.Printf("Warning: Skipping row %d (invalid address: %s)\n", i+2, record[0])
-			continue
-		}
-
-		// Parse amount
-		amount, ok := new(big.Int).SetString(record[1], 10)
-		if !ok {
-			fmt.Printf("Warning: Skipping row %d (invalid amount: %s)\n", i+2, record[1])
-			continue
-		}
-
-		addresses = append(addresses, address)
-		amounts = append(amounts, amount)
+	if *rpcURL == "" {
+		return fmt.Errorf("-rpc is required with -decimals-token")
 	}
 
-	if len(addresses) == 0 {
-		return nil, nil, fmt.Errorf("no valid entries found in CSV file")
+	client, err := util.NewEthClient(*rpcURL)
+	if err != nil {
+		return err
 	}
+	defer client.Close()
 
-	fmt.Printf("Processed %d valid entries out of %d total rows\n", len(addresses), len(records))
-	return addresses, amounts, nil
+	tokenDecimals, err := client.TokenDecimals(common.HexToAddress(*decimalToken))
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Token %s has %d decimals\n", common.HexToAddress(*decimalToken).Hex(), tokenDecimals)
+	opts.DecimalAmounts = true
+	opts.Decimals = tokenDecimals
+	return nil
 }
 
 func saveResults(addresses []common.Address, amounts []*big.Int, leaves []common.Hash, root common.Hash, csvFile string) {
@@ -0,0 +1,108 @@
+// Package util provides token amount parsing
+package util
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// MaxTokenDecimals is the largest value an ERC-20 decimals() uint8 can return
+const MaxTokenDecimals = 255
+
+// maxAmountExponent bounds scientific notation, far beyond any uint256 amount
+const maxAmountExponent = 1000
+
+// ParseDecimalAmount converts a human amount like "1,234.5" or "1.5e3" into base units of a token
+// with the given decimals. The conversion is exact: digits are shifted, never rounded, and an amount
+// with more precision than the token has is an error. Commas must separate groups of three digits.
+func ParseDecimalAmount(value string, decimals int) (*big.Int, error) {
+	if decimals < 0 || decimals > MaxTokenDecimals {
+		return nil, fmt.Errorf("decimals must be between 0 and %d, got %d", MaxTokenDecimals, decimals)
+	}
+
+	amount, err := parseDecimalAmount(value, decimals)
+	if err != nil {
+		return nil, fmt.Errorf("invalid amount %q: %w", value, err)
+	}
+	return amount, nil
+}
+
+// parseDecimalAmount does the work of ParseDecimalAmount, with errors that leave out the value
+func parseDecimalAmount(value string, decimals int) (*big.Int, error) {
+	s := strings.TrimPrefix(strings.TrimSpace(value), "+")
+	if strings.HasPrefix(s, "-") {
+		return nil, fmt.Errorf("negative amount")
+	}
+
+	mantissa, exponent := s, 0
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		mantissa = s[:i]
+		exp, err := strconv.Atoi(s[i+1:])
+		if err != nil || exp > maxAmountExponent || exp < -maxAmountExponent {
+			return nil, fmt.Errorf("invalid exponent")
+		}
+		exponent = exp
+	}
+
+	whole, fraction, _ := strings.Cut(mantissa, ".")
+	whole, err := removeThousandsSeparators(whole)
+	if err != nil {
+		return nil, err
+	}
+	if whole == "" && fraction == "" || !isDigits(whole) || !isDigits(fraction) {
+		return nil, fmt.Errorf("not a decimal number")
+	}
+
+	// Shift the decimal point right by decimals and the exponent
+	digits := whole + fraction
+	shift := decimals + exponent - len(fraction)
+	if shift >= 0 {
+		digits += strings.Repeat("0", shift)
+	} else {
+		cut := len(digits) + shift
+		if cut < 0 {
+			cut = 0
+		}
+		if strings.Trim(digits[cut:], "0") != "" {
+			return nil, fmt.Errorf("more precision than the token's %d decimals", decimals)
+		}
+		digits = digits[:cut]
+	}
+	if digits == "" {
+		digits = "0"
+	}
+
+	amount, ok := new(big.Int).SetString(digits, 10)
+	if !ok {
+		return nil, fmt.Errorf("not a decimal number")
+	}
+	return amount, nil
+}
+
+// removeThousandsSeparators strips commas from whole digits like "1,234,567"
+func removeThousandsSeparators(whole string) (string, error) {
+	if !strings.Contains(whole, ",") {
+		return whole, nil
+	}
+	groups := strings.Split(whole, ",")
+	if len(groups[0]) < 1 || len(groups[0]) > 3 {
+		return "", fmt.Errorf("misplaced thousands separator")
+	}
+	for _, group := range groups[1:] {
+		if len(group) != 3 {
+			return "", fmt.Errorf("misplaced thousands separator")
+		}
+	}
+	return strings.Join(groups, ""), nil
+}
+
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,93 @@
+package util
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseDecimalAmount(t *testing.T) {
+	cases := []struct {
+		value    string
+		decimals int
+		want     string
+	}{
+		{"1,234.5", 6, "1234500000"},
+		{"1234.5", 6, "1234500000"},
+		{"0.000001", 6, "1"},
+		{".5", 6, "500000"},
+		{"1.", 6, "1000000"},
+		{"1.5e3", 6, "1500000000"},
+		{"1.5E-5", 6, "15"},
+		{"2e-6", 6, "2"},
+		{"1.2300000", 6, "1230000"},
+		{"+7", 0, "7"},
+		{" 42 ", 18, "42000000000000000000"},
+		{"0.1", 18, "100000000000000000"},
+		{"1000e-3", 0, "1"},
+		{"0", 6, "0"},
+		// Precision no float64 could hold
+		{"123456789012345678901234567890.123456789012345678", 18, "123456789012345678901234567890123456789012345678"},
+	}
+	for _, c := range cases {
+		amount, err := ParseDecimalAmount(c.value, c.decimals)
+		if err != nil {
+			t.Errorf("ParseDecimalAmount(%q, %d): unexpected error: %v", c.value, c.decimals, err)
+			continue
+		}
+		if amount.String() != c.want {
+			t.Errorf("ParseDecimalAmount(%q, %d) = %s, want %s", c.value, c.decimals, amount, c.want)
+		}
+	}
+}
+
+func TestParseDecimalAmountErrors(t *testing.T) {
+	cases := []struct {
+		value    string
+		decimals int
+		reason   string
+	}{
+		{"1.0000001", 6, "precision"},
+		{"1e-7", 6, "precision"},
+		{"0.5", 0, "precision"},
+		{"-1", 6, "negative"},
+		{"1,5", 6, "separator"},
+		{"12,34.5", 6, "separator"},
+		{",123", 6, "separator"},
+		{"1.2.3", 6, "decimal number"},
+		{"abc", 6, "decimal number"},
+		{"", 6, "decimal number"},
+		{".", 6, "decimal number"},
+		{"1e", 6, "exponent"},
+		{"1e100000", 6, "exponent"},
+		{"1", 256, "decimals"},
+		{"1", -1, "decimals"},
+	}
+	for _, c := range cases {
+		_, err := ParseDecimalAmount(c.value, c.decimals)
+		if err == nil || !strings.Contains(err.Error(), c.reason) {
+			t.Errorf("ParseDecimalAmount(%q, %d): expected an error about %s, got %v", c.value, c.decimals, c.reason, err)
+		}
+	}
+}
+
+func TestReadAllocationsDecimalAmounts(t *testing.T) {
+	path := writeTestCSV(t, strings.Join([]string{
+		"address,amount",
+		`0x1111111111111111111111111111111111111111,"1,234.5"`,
+		"0x2222222222222222222222222222222222222222,2.5e-1",
+		"0x3333333333333333333333333333333333333333,0.0000001",
+	}, "\n"))
+
+	_, report, err := ReadAllocations(path, CSVOptions{DecimalAmounts: true, Decimals: 6})
+	if err == nil || len(report.Invalid) != 1 || report.Invalid[0].Line != 4 {
+		t.Fatalf("Expected line 4 to be rejected for excess precision, got %v", err)
+	}
+
+	testCases, _, err := ReadAllocations(path, CSVOptions{DecimalAmounts: true, Decimals: 6, SkipInvalid: true})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if testCases[0].Amount.String() != "1234500000" || testCases[1].Amount.String() != "250000" {
+		t.Errorf("Unexpected amounts %s and %s", testCases[0].Amount, testCases[1].Amount)
+	}
+}
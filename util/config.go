@@ -4,25 +4,227 @@ package util
 import (
 	"fmt"
 	"os"
+	"strings"
+	"time"
 
+	"github.com/ethereum/go-ethereum/common"
 	"gopkg.in/yaml.v3"
 )
 
 // Config represents the application configuration
 type Config struct {
-	RPC RPCConfig `yaml:"rpc"`
-	CSV CSVConfig `yaml:"csv"`
+	RPC      RPCConfig      `yaml:"rpc"`
+	CSV      CSVConfig      `yaml:"csv"`
+	Leaf     LeafConfig     `yaml:"leaf"`
+	Signer   SignerConfig   `yaml:"signer"`
+	Fees     FeesConfig     `yaml:"fees"`
+	Receipts ReceiptsConfig `yaml:"receipts"`
 }
 
 // RPCConfig contains RPC connection settings
 type RPCConfig struct {
 	Endpoint        string `yaml:"endpoint"`
 	ContractAddress string `yaml:"contract_address"`
+	// RequestsPerSecond limits requests to an http(s) endpoint; zero means no limit
+	RequestsPerSecond float64 `yaml:"requests_per_second"`
 }
 
 // CSVConfig contains CSV file settings for merkle tree data
 type CSVConfig struct {
 	FilePath string `yaml:"file_path"`
+	// Columns maps address and amount to header names, e.g. address: wallet
+	Columns map[string]string `yaml:"columns"`
+	// Duplicates is the policy for an address on several rows: error (default), sum, first or last
+	Duplicates string `yaml:"duplicates"`
+	// Decimals reads amounts in whole tokens, e.g. 1,234.5 for a 6-decimal token.
+	// DecimalsToken reads the decimals from the token contract instead.
+	Decimals      *int   `yaml:"decimals"`
+	DecimalsToken string `yaml:"decimals_token"`
+}
+
+// Options returns the CSV reader options; client is only used to read DecimalsToken
+func (c CSVConfig) Options(client *EthClient) (CSVOptions, error) {
+	policy, err := DuplicatePolicyByName(c.Duplicates)
+	if err != nil {
+		return CSVOptions{}, fmt.Errorf("invalid csv.duplicates: %w", err)
+	}
+	opts := CSVOptions{Columns: c.Columns, Duplicates: policy}
+
+	switch {
+	case c.Decimals != nil && c.DecimalsToken != "":
+		return CSVOptions{}, fmt.Errorf("csv.decimals and csv.decimals_token cannot both be set")
+	case c.Decimals != nil:
+		opts.DecimalAmounts = true
+		opts.Decimals = *c.Decimals
+	case c.DecimalsToken != "":
+		if !common.IsHexAddress(c.DecimalsToken) {
+			return CSVOptions{}, fmt.Errorf("invalid csv.decimals_token address %s", c.DecimalsToken)
+		}
+		if client == nil {
+			return CSVOptions{}, fmt.Errorf("csv.decimals_token needs an RPC connection")
+		}
+		decimals, err := client.TokenDecimals(common.HexToAddress(c.DecimalsToken))
+		if err != nil {
+			return CSVOptions{}, err
+		}
+		opts.DecimalAmounts = true
+		opts.Decimals = decimals
+	}
+	return opts, nil
+}
+
+// SignerConfig selects where claim keys come from; exactly one source must be set
+// Keys never live in the allocation CSV.
+type SignerConfig struct {
+	// KeyFile is an address,private_key CSV
+	KeyFile string `yaml:"key_file"`
+	// KeyEnv names an environment variable holding one or more hex private keys
+	KeyEnv string `yaml:"key_env"`
+	// KeystoreDir holds V3 keystore files and KeystoreFile is a single one. Both are decrypted with
+	// the passphrase in PassphraseFile, or one prompted for when it is empty.
+	KeystoreDir    string `yaml:"keystore_dir"`
+	KeystoreFile   string `yaml:"keystore_file"`
+	PassphraseFile string `yaml:"passphrase_file"`
+	// MnemonicFile holds a BIP-39 mnemonic, with its optional passphrase in PassphraseFile.
+	// HDAccounts consecutive accounts are derived from HDPath, m/44'/60'/0'/0/0 by default.
+	MnemonicFile string `yaml:"mnemonic_file"`
+	HDPath       string `yaml:"hd_path"`
+	HDAccounts   int    `yaml:"hd_accounts"`
+}
+
+// Signers opens the configured key source as a set of signers
+func (c SignerConfig) Signers() (*SignerSet, error) {
+	set := 0
+	for _, value := range []string{c.KeyFile, c.KeyEnv, c.KeystoreDir, c.KeystoreFile, c.MnemonicFile} {
+		if value != "" {
+			set++
+		}
+	}
+	if set != 1 {
+		return nil, fmt.Errorf("set exactly one of signer.key_file, signer.key_env, signer.keystore_dir, signer.keystore_file or signer.mnemonic_file")
+	}
+
+	switch {
+	case c.KeyFile != "":
+		return NewKeyFileSigners(c.KeyFile)
+	case c.KeyEnv != "":
+		return NewEnvSigners(c.KeyEnv)
+	case c.MnemonicFile != "":
+		mnemonic, err := os.ReadFile(c.MnemonicFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read mnemonic file: %w", err)
+		}
+		var passphrase string
+		if c.PassphraseFile != "" {
+			if passphrase, err = ReadPassphraseFile(c.PassphraseFile); err != nil {
+				return nil, err
+			}
+		}
+		path := c.HDPath
+		if path == "" {
+			path = DefaultHDPath
+		}
+		count := c.HDAccounts
+		if count == 0 {
+			count = 1
+		}
+		return NewMnemonicSigners(string(mnemonic), passphrase, path, count)
+	default:
+		passphrase, err := c.keystorePassphrase()
+		if err != nil {
+			return nil, err
+		}
+		if c.KeystoreFile != "" {
+			signer, err := NewKeystoreSigner(c.KeystoreFile, passphrase)
+			if err != nil {
+				return nil, err
+			}
+			return NewSignerSet(c.KeystoreFile, signer), nil
+		}
+		return NewKeystoreDirSigners(c.KeystoreDir, passphrase)
+	}
+}
+
+// keystorePassphrase reads PassphraseFile, or prompts when it is not set
+func (c SignerConfig) keystorePassphrase() (string, error) {
+	if c.PassphraseFile != "" {
+		return ReadPassphraseFile(c.PassphraseFile)
+	}
+	return PromptPassphrase("Keystore passphrase: ")
+}
+
+// FeesConfig contains transaction pricing settings
+type FeesConfig struct {
+	// Mode is auto (default), dynamic or legacy
+	Mode              string  `yaml:"mode"`
+	BaseFeeMultiplier float64 `yaml:"base_fee_multiplier"`
+	TipMultiplier     float64 `yaml:"tip_multiplier"`
+	// MaxFeeGwei and MaxTipGwei cap the max fee and tip per gas, in gwei like "30" or "1.5"
+	MaxFeeGwei string `yaml:"max_fee_gwei"`
+	MaxTipGwei string `yaml:"max_tip_gwei"`
+	// CeilingGwei holds claims back while the current gas price is above it
+	CeilingGwei string `yaml:"ceiling_gwei"`
+}
+
+// Options returns the fee options for EthClient.Fees
+func (c FeesConfig) Options() (FeeOptions, error) {
+	opts := FeeOptions{Mode: c.Mode, BaseFeeMultiplier: c.BaseFeeMultiplier, TipMultiplier: c.TipMultiplier}
+	if err := opts.Validate(); err != nil {
+		return FeeOptions{}, fmt.Errorf("invalid fees: %w", err)
+	}
+
+	var err error
+	if c.MaxFeeGwei != "" {
+		if opts.MaxFeePerGas, err = ParseDecimalAmount(c.MaxFeeGwei, 9); err != nil {
+			return FeeOptions{}, fmt.Errorf("invalid fees.max_fee_gwei: %w", err)
+		}
+	}
+	if c.MaxTipGwei != "" {
+		if opts.MaxPriorityFeePerGas, err = ParseDecimalAmount(c.MaxTipGwei, 9); err != nil {
+			return FeeOptions{}, fmt.Errorf("invalid fees.max_tip_gwei: %w", err)
+		}
+	}
+	if c.CeilingGwei != "" {
+		if opts.GasPriceCeiling, err = ParseDecimalAmount(c.CeilingGwei, 9); err != nil {
+			return FeeOptions{}, fmt.Errorf("invalid fees.ceiling_gwei: %w", err)
+		}
+	}
+	return opts, nil
+}
+
+// ReceiptsConfig contains settings for waiting on sent transactions
+type ReceiptsConfig struct {
+	// Confirmations counts the inclusion block; 1 (default) waits until the transaction is mined
+	Confirmations uint64 `yaml:"confirmations"`
+	// Timeout and PollInterval are durations like "5m" (default) and "2s" (default)
+	Timeout      string `yaml:"timeout"`
+	PollInterval string `yaml:"poll_interval"`
+}
+
+// Options returns the options for EthClient.WaitForReceipt
+func (c ReceiptsConfig) Options() (ReceiptOptions, error) {
+	opts := ReceiptOptions{Confirmations: c.Confirmations}
+	var err error
+	if c.Timeout != "" {
+		if opts.Timeout, err = time.ParseDuration(c.Timeout); err != nil || opts.Timeout <= 0 {
+			return ReceiptOptions{}, fmt.Errorf("invalid receipts.timeout %q, expected a positive duration like 5m", c.Timeout)
+		}
+	}
+	if c.PollInterval != "" {
+		if opts.PollInterval, err = time.ParseDuration(c.PollInterval); err != nil || opts.PollInterval <= 0 {
+			return ReceiptOptions{}, fmt.Errorf("invalid receipts.poll_interval %q, expected a positive duration like 2s", c.PollInterval)
+		}
+	}
+	return opts, nil
+}
+
+// ReadPassphraseFile reads a passphrase, dropping the trailing newline editors add
+func ReadPassphraseFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read passphrase file: %w", err)
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
 }
 
 // LoadConfig loads configuration from a YAML file
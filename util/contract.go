@@ -3,6 +3,7 @@ package util
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math/big"
 	"strings"
@@ -12,6 +13,8 @@ import (
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
 )
 
 // TokenClaimerABI contains the complete ABI for TokenClaimer contract
@@ -125,6 +128,86 @@ const TokenClaimerABI = `[
 		"outputs": [],
 		"stateMutability": "payable",
 		"type": "function"
+	},
+	{
+		"inputs": [],
+		"name": "merkleRoot",
+		"outputs": [
+			{
+				"internalType": "bytes32",
+				"name": "",
+				"type": "bytes32"
+			}
+		],
+		"stateMutability": "view",
+		"type": "function"
+	},
+	{
+		"inputs": [
+			{
+				"internalType": "address",
+				"name": "",
+				"type": "address"
+			}
+		],
+		"name": "claimed",
+		"outputs": [
+			{
+				"internalType": "bool",
+				"name": "",
+				"type": "bool"
+			}
+		],
+		"stateMutability": "view",
+		"type": "function"
+	},
+	{
+		"inputs": [
+			{
+				"internalType": "uint256",
+				"name": "index",
+				"type": "uint256"
+			}
+		],
+		"name": "isClaimed",
+		"outputs": [
+			{
+				"internalType": "bool",
+				"name": "",
+				"type": "bool"
+			}
+		],
+		"stateMutability": "view",
+		"type": "function"
+	},
+	{
+		"inputs": [],
+		"name": "token",
+		"outputs": [
+			{
+				"internalType": "address",
+				"name": "",
+				"type": "address"
+			}
+		],
+		"stateMutability": "view",
+		"type": "function"
+	},
+	{
+		"inputs": [
+			{
+				"internalType": "address",
+				"name": "account",
+				"type": "address"
+			}
+		],
+		"name": "AlreadyClaimed",
+		"type": "error"
+	},
+	{
+		"inputs": [],
+		"name": "InvalidProof",
+		"type": "error"
 	}
 ]`
 
@@ -155,6 +238,8 @@ type TestCase struct {
 	Name    string
 	Address common.Address
 	Amount  *big.Int
+	// Extra holds additional columns by name, for leaves with more fields than address and amount
+	Extra map[string]string
 }
 
 // GenerateMerkleRoot calls the contract's generateMerkleRoot function
@@ -271,23 +356,161 @@ func (tc *TokenClaimerContract) PrepareClaimTransaction(to common.Address, amoun
 	return data, nil
 }
 
+// call packs a view call, runs it against the latest block and unpacks its single result into out
+func (tc *TokenClaimerContract) call(out interface{}, method string, args ...interface{}) error {
+	data, err := tc.ABI.Pack(method, args...)
+	if err != nil {
+		return fmt.Errorf("failed to pack %s call: %w", method, err)
+	}
+
+	result, err := tc.Client.CallContract(context.Background(), ethereum.CallMsg{To: &tc.Address, Data: data}, nil)
+	if err != nil {
+		return fmt.Errorf("failed to call %s: %w", method, err)
+	}
+	if len(result) == 0 {
+		return fmt.Errorf("%s %w, does the contract have it?", method, errNoData)
+	}
+
+	if err := tc.ABI.UnpackIntoInterface(out, method, result); err != nil {
+		return fmt.Errorf("failed to unpack %s result: %w", method, err)
+	}
+	return nil
+}
+
+// MerkleRoot reads the root the contract verifies claims against
+func (tc *TokenClaimerContract) MerkleRoot() (common.Hash, error) {
+	var root common.Hash
+	err := tc.call(&root, "merkleRoot")
+	return root, err
+}
+
+// Claimed reports whether address has claimed, for contracts that track claims by address
+func (tc *TokenClaimerContract) Claimed(address common.Address) (bool, error) {
+	var claimed bool
+	err := tc.call(&claimed, "claimed", address)
+	return claimed, err
+}
+
+// IsClaimed reports whether the leaf at index has been claimed, for MerkleDistributor-style
+// contracts that track claims in a bitmap
+func (tc *TokenClaimerContract) IsClaimed(index uint64) (bool, error) {
+	var claimed bool
+	err := tc.call(&claimed, "isClaimed", new(big.Int).SetUint64(index))
+	return claimed, err
+}
+
+// errNoData marks calls that returned nothing, as calls to a method the contract lacks do
+var errNoData = errors.New("returned no data")
+
+// isMissingMethod reports whether a call failed the way calls to a method the contract lacks do:
+// with no return data, or with a revert from the contract's fallback
+func isMissingMethod(err error) bool {
+	if errors.Is(err, errNoData) {
+		return true
+	}
+	// Nodes report reverts with error code 3 or, without a code, as "execution reverted"
+	var rpcErr rpc.Error
+	if errors.As(err, &rpcErr) && rpcErr.ErrorCode() == 3 {
+		return true
+	}
+	return strings.Contains(err.Error(), "execution reverted")
+}
+
+// HasClaimed asks claimed(address), falling back to isClaimed(index) for contracts without it
+// Other failures, like timeouts or rate limits, are returned rather than hidden by the fallback.
+func (tc *TokenClaimerContract) HasClaimed(address common.Address, index uint64) (bool, error) {
+	claimed, err := tc.Claimed(address)
+	if err == nil {
+		return claimed, nil
+	}
+	if !isMissingMethod(err) {
+		return false, err
+	}
+	claimed, indexErr := tc.IsClaimed(index)
+	if indexErr != nil {
+		return false, fmt.Errorf("contract has neither claimed(address) nor isClaimed(uint256): %v; %v", err, indexErr)
+	}
+	return claimed, nil
+}
+
+// Token reads the address of the token the contract distributes
+func (tc *TokenClaimerContract) Token() (common.Address, error) {
+	var token common.Address
+	err := tc.call(&token, "token")
+	return token, err
+}
+
+// TokenBalance reads how many tokens the contract holds to pay claims with
+func (tc *TokenClaimerContract) TokenBalance() (common.Address, *big.Int, error) {
+	token, err := tc.Token()
+	if err != nil {
+		return common.Address{}, nil, err
+	}
+	balance, err := tc.Client.TokenBalance(token, tc.Address)
+	if err != nil {
+		return token, nil, err
+	}
+	return token, balance, nil
+}
+
+// CheckMerkleRoot fails unless the contract's stored root is localRoot, since every claim against a
+// different root would revert
+func (tc *TokenClaimerContract) CheckMerkleRoot(localRoot common.Hash) error {
+	onChain, err := tc.MerkleRoot()
+	if err != nil {
+		return fmt.Errorf("failed to read the contract's merkle root: %w", err)
+	}
+	if onChain != localRoot {
+		return fmt.Errorf("contract merkle root %s does not match the local root %s; is this the right allocation list and leaf format?", onChain.Hex(), localRoot.Hex())
+	}
+	return nil
+}
+
+// RevertReason explains why a mined transaction to the contract reverted, decoding Error(string),
+// panics and the contract's custom errors
+func (tc *TokenClaimerContract) RevertReason(ctx context.Context, tx *types.Transaction, receipt *types.Receipt) (string, error) {
+	data, err := tc.Client.RevertData(ctx, tx, receipt.BlockNumber)
+	if err != nil {
+		return "", err
+	}
+	if data == nil {
+		if receipt.GasUsed >= tx.Gas() {
+			return fmt.Sprintf("out of gas (used all %d)", tx.Gas()), nil
+		}
+		return "reverted, but the replay succeeded", nil
+	}
+	return DecodeRevertReason(tc.ABI, data), nil
+}
+
 // MerkleData contains all merkle tree related data
 type MerkleData struct {
-	Root   common.Hash
-	Leaves []common.Hash
-	Tree   *merkle.MerkleTree
+	Root    common.Hash
+	Leaves  []common.Hash
+	Tree    *merkle.MerkleTree
+	Encoder *LeafEncoder
 }
 
 // GenerateLocalMerkleData generates local merkle tree data from test cases
+// Leaves are keccak256(abi.encodePacked(address, amount)), like TokenClaimer.sol
 func GenerateLocalMerkleData(testCases []TestCase) (*MerkleData, error) {
+	return GenerateLocalMerkleDataWithEncoder(testCases, DefaultLeafEncoder())
+}
+
+// GenerateLocalMerkleDataWithEncoder generates local merkle tree data using a custom leaf encoding
+// Tree options select the hash function and pair strategy, defaulting to TokenClaimer.sol's
+func GenerateLocalMerkleDataWithEncoder(testCases []TestCase, encoder *LeafEncoder, opts ...merkle.Option) (*MerkleData, error) {
 	// Generate leaves from test cases
 	leaves := make([]common.Hash, len(testCases))
 	for i, testCase := range testCases {
-		leaves[i] = merkle.HashAddressAmount(testCase.Address, testCase.Amount)
+		leaf, err := encoder.Hash(i, testCase)
+		if err != nil {
+			return nil, err
+		}
+		leaves[i] = leaf
 	}
 
 	// Create Merkle tree
-	tree, err := merkle.NewMerkleTree(leaves)
+	tree, err := merkle.NewMerkleTree(leaves, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create merkle tree: %w", err)
 	}
@@ -295,9 +518,10 @@ func GenerateLocalMerkleData(testCases []TestCase) (*MerkleData, error) {
 	root := tree.GenerateRoot()
 
 	return &MerkleData{
-		Root:   root,
-		Leaves: leaves,
-		Tree:   tree,
+		Root:    root,
+		Leaves:  leaves,
+		Tree:    tree,
+		Encoder: encoder,
 	}, nil
 }
 
@@ -307,7 +531,7 @@ func (md *MerkleData) GenerateLocalProof(index int) ([]common.Hash, error) {
 		return nil, fmt.Errorf("invalid index: %d", index)
 	}
 
-	proof, err := md.Tree.GenerateProof(md.Leaves[index])
+	proof, err := md.Tree.GenerateProofAt(index)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate proof: %w", err)
 	}
@@ -0,0 +1,115 @@
+package util
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// callError is a JSON-RPC error with a code, like the ones nodes send for reverts and rate limits
+type callError struct {
+	code    int
+	message string
+}
+
+func (e *callError) Error() string  { return e.message }
+func (e *callError) ErrorCode() int { return e.code }
+
+// fakeContract serves eth_call, answering each method by its name
+type fakeContract struct {
+	mu      sync.Mutex
+	abi     map[string]string
+	answers map[string]func() (hexutil.Bytes, error)
+	calls   []string
+}
+
+type callArgs struct {
+	Data  hexutil.Bytes `json:"data"`
+	Input hexutil.Bytes `json:"input"`
+}
+
+func (c *fakeContract) Call(args callArgs, block string) (hexutil.Bytes, error) {
+	data := args.Input
+	if len(data) == 0 {
+		data = args.Data
+	}
+	method := c.abi[hexutil.Encode(data[:4])]
+
+	c.mu.Lock()
+	c.calls = append(c.calls, method)
+	c.mu.Unlock()
+	return c.answers[method]()
+}
+
+func newFakeContract(t *testing.T, answers map[string]func() (hexutil.Bytes, error)) (*TokenClaimerContract, *fakeContract) {
+	t.Helper()
+	fake := &fakeContract{abi: make(map[string]string), answers: answers}
+	server := rpc.NewServer()
+	if err := server.RegisterName("eth", fake); err != nil {
+		t.Fatalf("Failed to register fake contract: %v", err)
+	}
+	t.Cleanup(server.Stop)
+
+	client := &EthClient{Client: ethclient.NewClient(rpc.DialInProc(server)), ChainID: big.NewInt(1337)}
+	contract, err := NewTokenClaimerContract("0x1111111111111111111111111111111111111111", client)
+	if err != nil {
+		t.Fatalf("Failed to create contract: %v", err)
+	}
+	for name, method := range contract.ABI.Methods {
+		fake.abi[hexutil.Encode(method.ID)] = name
+	}
+	return contract, fake
+}
+
+func TestHasClaimed(t *testing.T) {
+	yes := func() (hexutil.Bytes, error) { return common.LeftPadBytes([]byte{1}, 32), nil }
+	tests := []struct {
+		name      string
+		claimed   func() (hexutil.Bytes, error)
+		wantCalls string
+		wantErr   string
+	}{
+		{"claimed(address)", yes, "claimed", ""},
+		{"no data", func() (hexutil.Bytes, error) { return nil, nil }, "claimed,isClaimed", ""},
+		{"revert with code", func() (hexutil.Bytes, error) {
+			return nil, &callError{code: 3, message: "execution reverted"}
+		}, "claimed,isClaimed", ""},
+		{"revert without code", func() (hexutil.Bytes, error) {
+			return nil, errors.New("execution reverted")
+		}, "claimed,isClaimed", ""},
+		{"rate limited", func() (hexutil.Bytes, error) {
+			return nil, &callError{code: -32005, message: "rate limit exceeded"}
+		}, "claimed", "rate limit exceeded"},
+		{"node error", func() (hexutil.Bytes, error) {
+			return nil, errors.New("header not found")
+		}, "claimed", "header not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			contract, fake := newFakeContract(t, map[string]func() (hexutil.Bytes, error){
+				"claimed":   tt.claimed,
+				"isClaimed": yes,
+			})
+
+			claimed, err := contract.HasClaimed(common.HexToAddress("0x742d35Cc6634C0532925A3B8D4C9dB96C4B4d8B6"), 3)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Expected error %q, got %v", tt.wantErr, err)
+				}
+			} else if err != nil || !claimed {
+				t.Errorf("Expected claimed, got %v, %v", claimed, err)
+			}
+			if calls := strings.Join(fake.calls, ","); calls != tt.wantCalls {
+				t.Errorf("Expected calls %s, got %s", tt.wantCalls, calls)
+			}
+		})
+	}
+}
@@ -3,76 +3,491 @@ package util
 
 import (
 	"encoding/csv"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
 	"math/big"
 	"os"
+	"strings"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
 )
 
-// ClaimerInfo contains information about a claimer from CSV
-type ClaimerInfo struct {
-	Name       string
+// Column roles a CSV column can be mapped to
+const (
+	ColumnAddress    = "address"
+	ColumnAmount     = "amount"
+	ColumnPrivateKey = "private_key"
+)
+
+// CSVOptions configures how ReadCSV finds and validates columns
+type CSVOptions struct {
+	// Columns maps a role to the header name of its column, e.g. {"address": "wallet"}.
+	// Roles that are not mapped use a column named after the role.
+	Columns map[string]string
+	// Positional lists the role of each column when the file has no header row.
+	// It also decides which roles are required.
+	Positional []string
+	// SkipInvalid drops bad rows and lists them in the report instead of failing the read
+	SkipInvalid bool
+	// Duplicates decides what happens to an address on more than one row
+	Duplicates DuplicatePolicy
+	// DecimalAmounts reads amounts like "1,234.5" in whole tokens and scales them by Decimals.
+	// Otherwise amounts are integers in base units.
+	DecimalAmounts bool
+	Decimals       int
+}
+
+// CSVRecord is one valid data row
+type CSVRecord struct {
+	Line       int
 	Address    common.Address
-	PrivateKey string
 	Amount     *big.Int
+	PrivateKey string
+	// Extra holds the columns not mapped to a role, keyed by header name
+	Extra map[string]string
 }
 
-// ReadClaimersFromCSV reads claimer information from a CSV file
-// CSV format: address,private_key,amount
-func ReadClaimersFromCSV(filePath string) ([]ClaimerInfo, error) {
+// CSVRowError describes why a row was rejected
+type CSVRowError struct {
+	Line   int
+	Column string
+	Value  string
+	Reason string
+}
+
+func (e CSVRowError) String() string {
+	if e.Column == "" {
+		return fmt.Sprintf("line %d: %s", e.Line, e.Reason)
+	}
+	return fmt.Sprintf("line %d: %s %q: %s", e.Line, e.Column, e.Value, e.Reason)
+}
+
+// CSVReport summarizes a read: the header if one was found, the row counts and every bad row
+type CSVReport struct {
+	Path      string
+	Header    []string
+	Rows      int
+	ValidRows int
+	Invalid   []CSVRowError
+	// Duplicates lists the addresses found on more than one valid row
+	Duplicates *DuplicateReport
+}
+
+// CSVReportError fails a strict read, listing every bad row rather than only the first
+type CSVReportError struct {
+	Report *CSVReport
+}
+
+func (e *CSVReportError) Error() string {
+	// A row with several problems lists each of them but counts once
+	lines := make(map[int]bool, len(e.Report.Invalid))
+	for _, invalid := range e.Report.Invalid {
+		lines[invalid.Line] = true
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d of %d rows in %s are invalid:", len(lines), e.Report.Rows, e.Report.Path)
+	for _, invalid := range e.Report.Invalid {
+		b.WriteString("\n  ")
+		b.WriteString(invalid.String())
+	}
+	return b.String()
+}
+
+// ParseColumnMapping parses a mapping like "address=wallet,amount=qty"
+func ParseColumnMapping(s string) (map[string]string, error) {
+	columns := make(map[string]string)
+	if strings.TrimSpace(s) == "" {
+		return columns, nil
+	}
+	for _, pair := range strings.Split(s, ",") {
+		role, name, ok := strings.Cut(pair, "=")
+		role = strings.ToLower(strings.TrimSpace(role))
+		name = strings.TrimSpace(name)
+		if !ok || role == "" || name == "" {
+			return nil, fmt.Errorf("invalid column mapping %q, expected role=header", pair)
+		}
+		if !isColumnRole(role) {
+			return nil, fmt.Errorf("unknown column role %q, expected %s, %s or %s", role, ColumnAddress, ColumnAmount, ColumnPrivateKey)
+		}
+		if _, exists := columns[role]; exists {
+			return nil, fmt.Errorf("column role %q is mapped twice", role)
+		}
+		columns[role] = name
+	}
+	return columns, nil
+}
+
+func isColumnRole(role string) bool {
+	return role == ColumnAddress || role == ColumnAmount || role == ColumnPrivateKey
+}
+
+// ReadCSV reads and validates every row of a CSV file.
+// The first row is a header unless its address cell holds an address; a column mapping requires a header.
+// Addresses must be valid, non-zero and, when mixed-case, carry a correct EIP-55 checksum. Amounts are
+// non-negative base-10 integers. Without SkipInvalid any bad row fails the read with a *CSVReportError.
+func ReadCSV(filePath string, opts CSVOptions) ([]CSVRecord, *CSVReport, error) {
 	file, err := os.Open(filePath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open CSV file: %w", err)
+		return nil, nil, fmt.Errorf("failed to open CSV file: %w", err)
 	}
 	defer file.Close()
 
-	reader := csv.NewReader(file)
-	records, err := reader.ReadAll()
-	if err != nil {
-		return nil, fmt.Errorf("failed to read CSV file: %w", err)
+	return readCSV(file, filePath, opts)
+}
+
+func readCSV(r io.Reader, name string, opts CSVOptions) ([]CSVRecord, *CSVReport, error) {
+	positional := opts.Positional
+	if len(positional) == 0 {
+		positional = []string{ColumnAddress, ColumnAmount}
+	}
+	for role := range opts.Columns {
+		if !isColumnRole(role) {
+			return nil, nil, fmt.Errorf("unknown column role %q", role)
+		}
+	}
+	if opts.DecimalAmounts && (opts.Decimals < 0 || opts.Decimals > MaxTokenDecimals) {
+		return nil, nil, fmt.Errorf("decimals must be between 0 and %d, got %d", MaxTokenDecimals, opts.Decimals)
+	}
+
+	reader := csv.NewReader(r)
+	// Rows with the wrong number of fields are reported like any other bad row
+	reader.FieldsPerRecord = -1
+	report := &CSVReport{Path: name}
+
+	var (
+		records []CSVRecord
+		columns map[string]int
+		extras  map[int]string
+		first   = true
+	)
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				return nil, nil, fmt.Errorf("failed to read CSV file: %w", err)
+			}
+			report.Rows++
+			report.Invalid = append(report.Invalid, CSVRowError{Line: parseErr.Line, Reason: parseErr.Err.Error()})
+			continue
+		}
+		line, _ := reader.FieldPos(0)
+
+		if first {
+			first = false
+			columns, extras, err = resolveColumns(record, positional, opts.Columns)
+			if err != nil {
+				return nil, nil, fmt.Errorf("%s: %w", name, err)
+			}
+			if extras != nil {
+				report.Header = record
+				continue
+			}
+		}
+
+		report.Rows++
+		parsed, invalid := parseCSVRow(record, line, columns, extras, opts)
+		if len(invalid) > 0 {
+			report.Invalid = append(report.Invalid, invalid...)
+			continue
+		}
+		records = append(records, parsed)
 	}
+	report.ValidRows = len(records)
 
+	if len(report.Invalid) > 0 && !opts.SkipInvalid {
+		return nil, report, &CSVReportError{Report: report}
+	}
 	if len(records) == 0 {
-		return nil, fmt.Errorf("CSV file is empty")
+		return nil, report, fmt.Errorf("no valid rows in %s", name)
 	}
 
-	claimers := make([]ClaimerInfo, len(records))
+	records, duplicates, err := applyCSVDuplicatePolicy(records, opts.Duplicates)
+	report.Duplicates = duplicates
+	if err != nil {
+		return nil, report, fmt.Errorf("%s: %w", name, err)
+	}
+	return records, report, nil
+}
+
+// applyCSVDuplicatePolicy merges rows of the same address, reporting them by line
+// Summed rows must also agree on their private key.
+func applyCSVDuplicatePolicy(records []CSVRecord, policy DuplicatePolicy) ([]CSVRecord, *DuplicateReport, error) {
+	entries := make([]duplicateEntry, len(records))
 	for i, record := range records {
-		if len(record) != 3 {
-			return nil, fmt.Errorf("invalid CSV format at line %d: expected 3 columns (address,private_key,amount), got %d", i+1, len(record))
+		extra := record.Extra
+		if record.PrivateKey != "" {
+			extra = make(map[string]string, len(record.Extra)+1)
+			for key, value := range record.Extra {
+				extra[key] = value
+			}
+			extra[ColumnPrivateKey] = record.PrivateKey
+		}
+		entries[i] = duplicateEntry{
+			address:  record.Address,
+			amount:   record.Amount,
+			extra:    extra,
+			location: fmt.Sprintf("line %d", record.Line),
+		}
+	}
+
+	kept, report, err := resolveDuplicates(entries, policy)
+	if report != nil {
+		for i := range report.Addresses {
+			duplicate := &report.Addresses[i]
+			for _, entry := range duplicate.Entries {
+				duplicate.Lines = append(duplicate.Lines, records[entry].Line)
+			}
+		}
+	}
+	if err != nil {
+		return nil, report, err
+	}
+	if kept == nil {
+		return records, report, nil
+	}
+
+	result := make([]CSVRecord, len(kept))
+	for i, k := range kept {
+		result[i] = records[k.index]
+		result[i].Amount = k.amount
+	}
+	return result, report, nil
+}
+
+// resolveColumns maps each role to a column index.
+// extras is nil when the first row is data rather than a header.
+func resolveColumns(first []string, positional []string, mapping map[string]string) (map[string]int, map[int]string, error) {
+	// Spreadsheets often start the file with a byte order mark
+	if len(first) > 0 {
+		first[0] = strings.TrimPrefix(first[0], "\ufeff")
+	}
+
+	addressIndex := -1
+	for i, role := range positional {
+		if role == ColumnAddress {
+			addressIndex = i
 		}
+	}
 
-		// Parse address
-		address := common.HexToAddress(record[0])
+	// A first row whose address cell looks like neither an address nor an attempt at one is a header
+	isHeader := len(mapping) > 0 || addressIndex < 0 || addressIndex >= len(first)
+	if !isHeader {
+		cell := strings.TrimSpace(first[addressIndex])
+		isHeader = !common.IsHexAddress(cell) && !strings.HasPrefix(strings.ToLower(cell), "0x")
+	}
 
-		// Get private key
-		privateKey := record[1]
+	columns := make(map[string]int, len(positional))
+	if !isHeader {
+		for i, role := range positional {
+			columns[role] = i
+		}
+		return columns, nil, nil
+	}
 
-		// Parse amount
-		amount, ok := new(big.Int).SetString(record[2], 10)
+	byName := make(map[string]int, len(first))
+	for i, name := range first {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if _, exists := byName[name]; exists {
+			return nil, nil, fmt.Errorf("header has column %q twice", first[i])
+		}
+		byName[name] = i
+	}
+	for _, role := range positional {
+		name := role
+		if mapped, ok := mapping[role]; ok {
+			name = mapped
+		}
+		index, ok := byName[strings.ToLower(name)]
 		if !ok {
-			return nil, fmt.Errorf("invalid amount at line %d: %s", i+1, record[2])
+			return nil, nil, fmt.Errorf("header has no %q column for the %s (header: %s)", name, role, strings.Join(first, ","))
 		}
+		columns[role] = index
+	}
 
-		claimers[i] = ClaimerInfo{
-			Name:       fmt.Sprintf("Claimer_%d", i+1),
-			Address:    address,
-			PrivateKey: privateKey,
-			Amount:     amount,
+	// A key column is never carried along as an extra, where it would end up in artifacts and proofs
+	extras := make(map[int]string)
+	for i, name := range first {
+		if !isMappedColumn(columns, i) && !strings.EqualFold(strings.TrimSpace(name), privateKeyColumn(mapping)) {
+			extras[i] = strings.TrimSpace(name)
 		}
 	}
+	return columns, extras, nil
+}
 
-	return claimers, nil
+// privateKeyColumn returns the header name of the private key column
+func privateKeyColumn(mapping map[string]string) string {
+	if name, ok := mapping[ColumnPrivateKey]; ok {
+		return name
+	}
+	return ColumnPrivateKey
 }
 
-// ReadCSVTestCases reads test cases from claimer info (for merkle tree generation)
-func ReadCSVTestCases(filePath string) ([]TestCase, error) {
-	claimers, err := ReadClaimersFromCSV(filePath)
+func isMappedColumn(columns map[string]int, index int) bool {
+	for _, i := range columns {
+		if i == index {
+			return true
+		}
+	}
+	return false
+}
+
+// parseCSVRow validates one data row, returning every problem found in it
+func parseCSVRow(record []string, line int, columns map[string]int, extras map[int]string, opts CSVOptions) (CSVRecord, []CSVRowError) {
+	parsed := CSVRecord{Line: line, Extra: make(map[string]string)}
+
+	needed := 0
+	for _, index := range columns {
+		if index+1 > needed {
+			needed = index + 1
+		}
+	}
+	if len(record) < needed {
+		return parsed, []CSVRowError{{Line: line, Reason: fmt.Sprintf("expected at least %d columns, got %d", needed, len(record))}}
+	}
+
+	var invalid []CSVRowError
+	if index, ok := columns[ColumnAddress]; ok {
+		value := strings.TrimSpace(record[index])
+		address, reason := parseCSVAddress(value)
+		if reason != "" {
+			invalid = append(invalid, CSVRowError{Line: line, Column: ColumnAddress, Value: reportedCell(value), Reason: reason})
+		}
+		parsed.Address = address
+	}
+	if index, ok := columns[ColumnAmount]; ok {
+		value := strings.TrimSpace(record[index])
+		amount, reason := parseCSVAmount(value, opts)
+		if reason != "" {
+			invalid = append(invalid, CSVRowError{Line: line, Column: ColumnAmount, Value: reportedCell(value), Reason: reason})
+		}
+		parsed.Amount = amount
+	}
+	if index, ok := columns[ColumnPrivateKey]; ok {
+		value := strings.TrimSpace(record[index])
+		// Never echo a key into the report
+		if _, err := crypto.HexToECDSA(strings.TrimPrefix(strings.TrimPrefix(value, "0x"), "0X")); err != nil {
+			invalid = append(invalid, CSVRowError{Line: line, Column: ColumnPrivateKey, Value: redacted, Reason: "invalid private key"})
+		}
+		parsed.PrivateKey = value
+	}
+
+	if len(invalid) > 0 {
+		return parsed, invalid
+	}
+	for index, name := range extras {
+		if index < len(record) {
+			parsed.Extra[name] = strings.TrimSpace(record[index])
+		}
+	}
+	return parsed, nil
+}
+
+// redacted stands in for a cell that may hold a private key
+const redacted = "<redacted>"
+
+// maxReportedCell is how much of a bad cell a report shows, enough for an address
+const maxReportedCell = 42
+
+// reportedCell returns a bad cell as a report may show it: a value shaped like a private key, as in
+// a legacy address,private_key,amount file, is redacted and long values are truncated
+func reportedCell(value string) string {
+	digits := strings.TrimPrefix(strings.TrimPrefix(value, "0x"), "0X")
+	if _, err := hex.DecodeString(digits); err == nil && len(digits) == 64 {
+		return redacted
+	}
+	if runes := []rune(value); len(runes) > maxReportedCell {
+		return string(runes[:maxReportedCell]) + "…"
+	}
+	return value
+}
+
+// parseCSVAmount returns the amount in base units or the reason it is rejected
+func parseCSVAmount(value string, opts CSVOptions) (*big.Int, string) {
+	if opts.DecimalAmounts {
+		amount, err := parseDecimalAmount(value, opts.Decimals)
+		if err != nil {
+			return nil, err.Error()
+		}
+		return amount, ""
+	}
+
+	amount, ok := new(big.Int).SetString(value, 10)
+	switch {
+	case !ok:
+		return nil, "not a base-10 integer"
+	case amount.Sign() < 0:
+		return amount, "negative amount"
+	}
+	return amount, ""
+}
+
+// parseCSVAddress returns the address or the reason it is rejected
+func parseCSVAddress(value string) (common.Address, string) {
+	if !common.IsHexAddress(value) {
+		return common.Address{}, "not a hex address"
+	}
+	address := common.HexToAddress(value)
+	if address == (common.Address{}) {
+		return address, "zero address"
+	}
+	digits := strings.TrimPrefix(strings.TrimPrefix(value, "0x"), "0X")
+	if digits != strings.ToLower(digits) && digits != strings.ToUpper(digits) && "0x"+digits != address.Hex() {
+		return address, fmt.Sprintf("bad checksum, expected %s", address.Hex())
+	}
+	return address, ""
+}
+
+// ClaimerInfo contains information about a claimer from CSV
+type ClaimerInfo struct {
+	Name       string
+	Address    common.Address
+	PrivateKey string
+	Amount     *big.Int
+}
+
+// ReadClaimersFromCSV reads claimer information from a CSV file
+// CSV format: address,private_key,amount, with or without a header row
+//
+// Deprecated: keep keys out of allocation lists; read them with ReadAllocations and a SignerSet.
+func ReadClaimersFromCSV(filePath string) ([]ClaimerInfo, error) {
+	claimers, _, err := ReadClaimers(filePath, CSVOptions{})
+	return claimers, err
+}
+
+// ReadClaimers reads claimer information with a column mapping
+// Headerless files use the address,private_key,amount column order.
+//
+// Deprecated: keep keys out of allocation lists; read them with ReadAllocations and a SignerSet.
+func ReadClaimers(filePath string, opts CSVOptions) ([]ClaimerInfo, *CSVReport, error) {
+	opts.Positional = []string{ColumnAddress, ColumnPrivateKey, ColumnAmount}
+	records, report, err := ReadCSV(filePath, opts)
 	if err != nil {
-		return nil, err
+		return nil, report, err
 	}
 
+	claimers := make([]ClaimerInfo, len(records))
+	for i, record := range records {
+		claimers[i] = ClaimerInfo{
+			Name:       fmt.Sprintf("Claimer_%d", i+1),
+			Address:    record.Address,
+			PrivateKey: record.PrivateKey,
+			Amount:     record.Amount,
+		}
+	}
+
+	return claimers, report, nil
+}
+
+// ClaimersToTestCases turns claimers into test cases (for merkle tree generation)
+func ClaimersToTestCases(claimers []ClaimerInfo) []TestCase {
 	testCases := make([]TestCase, len(claimers))
 	for i, claimer := range claimers {
 		testCases[i] = TestCase{
@@ -81,8 +496,17 @@ func ReadCSVTestCases(filePath string) ([]TestCase, error) {
 			Amount:  claimer.Amount,
 		}
 	}
+	return testCases
+}
 
-	return testCases, nil
+// ReadCSVTestCases reads test cases from claimer info (for merkle tree generation)
+func ReadCSVTestCases(filePath string) ([]TestCase, error) {
+	claimers, err := ReadClaimersFromCSV(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	return ClaimersToTestCases(claimers), nil
 }
 
 // ReadCSVAddressesAndAmounts reads addresses and amounts from CSV file
@@ -103,3 +527,40 @@ func ReadCSVAddressesAndAmounts(filePath string) ([]common.Address, []*big.Int,
 
 	return addresses, amounts, nil
 }
+
+// ReadAllocationsFromCSV reads an allocation list (address,amount), with or without a header row
+// Columns not mapped to the address or amount are kept in TestCase.Extra keyed by their header name
+func ReadAllocationsFromCSV(filePath string) ([]TestCase, error) {
+	testCases, _, err := ReadAllocations(filePath, CSVOptions{})
+	return testCases, err
+}
+
+// ReadAllocations reads an allocation list with a column mapping
+// Headerless files use the address,amount column order. A file with a private key column is
+// rejected: keys belong in a signer source, not next to the allocations that get published.
+func ReadAllocations(filePath string, opts CSVOptions) ([]TestCase, *CSVReport, error) {
+	opts.Positional = []string{ColumnAddress, ColumnAmount}
+	records, report, err := ReadCSV(filePath, opts)
+	if report != nil {
+		for _, name := range report.Header {
+			if strings.EqualFold(strings.TrimSpace(name), privateKeyColumn(opts.Columns)) {
+				return nil, report, fmt.Errorf("%s has a %q column; move the keys to a signer source and remove the column", filePath, name)
+			}
+		}
+	}
+	if err != nil {
+		return nil, report, err
+	}
+
+	testCases := make([]TestCase, len(records))
+	for i, record := range records {
+		testCases[i] = TestCase{
+			Name:    fmt.Sprintf("Claimer_%d", i+1),
+			Address: record.Address,
+			Amount:  record.Amount,
+			Extra:   record.Extra,
+		}
+	}
+
+	return testCases, report, nil
+}
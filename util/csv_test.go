@@ -0,0 +1,173 @@
+package util
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeTestCSV(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "input.csv")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("Failed to write CSV: %v", err)
+	}
+	return path
+}
+
+func TestReadAllocationsHeaderDetection(t *testing.T) {
+	withHeader := writeTestCSV(t, "address,amount,tokenId\n0x1111111111111111111111111111111111111111,100,7\n")
+	testCases, report, err := ReadAllocations(withHeader, CSVOptions{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(report.Header) != 3 || len(testCases) != 1 || testCases[0].Extra["tokenId"] != "7" {
+		t.Errorf("Expected a header and the tokenId extra, got %+v and %+v", report.Header, testCases)
+	}
+
+	withoutHeader := writeTestCSV(t, "0x1111111111111111111111111111111111111111,100\n0x2222222222222222222222222222222222222222,200\n")
+	testCases, report, err = ReadAllocations(withoutHeader, CSVOptions{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if report.Header != nil || len(testCases) != 2 || testCases[1].Amount.String() != "200" {
+		t.Errorf("Expected two data rows and no header, got %+v and %+v", report.Header, testCases)
+	}
+}
+
+func TestReadAllocationsColumnMapping(t *testing.T) {
+	path := writeTestCSV(t, "qty,note,Wallet\n5,first,0x1111111111111111111111111111111111111111\n")
+
+	columns, err := ParseColumnMapping("address=wallet,amount=qty")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	testCases, _, err := ReadAllocations(path, CSVOptions{Columns: columns})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if testCases[0].Amount.String() != "5" || testCases[0].Extra["note"] != "first" {
+		t.Errorf("Unexpected test case: %+v", testCases[0])
+	}
+
+	if _, _, err := ReadAllocations(path, CSVOptions{Columns: map[string]string{ColumnAddress: "account"}}); err == nil {
+		t.Error("Expected an error for a mapped column missing from the header")
+	}
+
+	for _, invalid := range []string{"address", "address=", "wallet=address", "address=a,address=b"} {
+		if _, err := ParseColumnMapping(invalid); err == nil {
+			t.Errorf("Expected an error for mapping %q", invalid)
+		}
+	}
+}
+
+func TestReadAllocationsReportsEveryBadRow(t *testing.T) {
+	path := writeTestCSV(t, strings.Join([]string{
+		"address,amount",
+		"0x1111111111111111111111111111111111111111,100",
+		"0x0000000000000000000000000000000000000000,100",
+		"0x742d35Cc6634C0532925a3b8D4C9db96C4b4d8b6,100",
+		"0x2222222222222222222222222222222222222222,-1",
+		"0x3333333333333333333333333333333333333333",
+		"0x4444,abc",
+		"0x742d35Cc6634C0532925A3B8D4C9dB96C4B4d8B6,300",
+	}, "\n"))
+
+	_, report, err := ReadAllocations(path, CSVOptions{})
+	var reportErr *CSVReportError
+	if !errors.As(err, &reportErr) {
+		t.Fatalf("Expected a CSVReportError, got %v", err)
+	}
+
+	// Line 7 has two problems, each is reported
+	wantLines := []int{3, 4, 5, 6, 7, 7}
+	if len(report.Invalid) != len(wantLines) {
+		t.Fatalf("Expected %d problems, got %d:\n%v", len(wantLines), len(report.Invalid), err)
+	}
+	for i, line := range wantLines {
+		if report.Invalid[i].Line != line {
+			t.Errorf("Problem %d: expected line %d, got %s", i, line, report.Invalid[i])
+		}
+	}
+	if !strings.Contains(err.Error(), "5 of 7 rows") {
+		t.Errorf("Expected 5 of 7 rows to be counted as invalid, got %v", err)
+	}
+	if !strings.Contains(report.Invalid[0].Reason, "zero address") || !strings.Contains(report.Invalid[1].Reason, "checksum") {
+		t.Errorf("Unexpected reasons: %v", report.Invalid[:2])
+	}
+
+	testCases, report, err := ReadAllocations(path, CSVOptions{SkipInvalid: true})
+	if err != nil {
+		t.Fatalf("Unexpected error with SkipInvalid: %v", err)
+	}
+	if len(testCases) != 2 || report.Rows != 7 || report.ValidRows != 2 {
+		t.Errorf("Expected 2 of 7 rows to be kept, got %d (report %+v)", len(testCases), report)
+	}
+}
+
+func TestReadAllocationsRejectsPrivateKeys(t *testing.T) {
+	key := "4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318"
+	path := writeTestCSV(t, "address,private_key,amount,note\n0x2c7536E3605D9C16a7a3D7b1898e529396a65c23,0x"+key+",100,first\n")
+
+	if _, _, err := ReadAllocations(path, CSVOptions{}); err == nil || strings.Contains(err.Error(), key) {
+		t.Errorf("Expected a private key column error that does not echo the key, got %v", err)
+	}
+
+	// Read as a plain CSV, the key column is left out of the extras, so no key reaches the artifact
+	records, _, err := ReadCSV(path, CSVOptions{Positional: []string{ColumnAddress, ColumnAmount}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	testCases := []TestCase{{Address: records[0].Address, Amount: records[0].Amount, Extra: records[0].Extra}}
+	distribution, err := BuildDistribution(testCases, DefaultLeafEncoder(), TreeConfig{})
+	if err != nil {
+		t.Fatalf("Failed to build distribution: %v", err)
+	}
+	var artifact bytes.Buffer
+	if err := distribution.WriteArtifact(&artifact); err != nil {
+		t.Fatalf("Failed to write artifact: %v", err)
+	}
+	if strings.Contains(artifact.String(), key) || !strings.Contains(artifact.String(), `"note":"first"`) {
+		t.Errorf("Expected the note but no key in the artifact, got %s", artifact.String())
+	}
+}
+
+func TestReadAllocationsRedactsBadCells(t *testing.T) {
+	key := "ac0974bec39a17e36ba4a6b4d238ff944bacb478cbed5efcae784d7bf4f2ff80"
+	long := strings.Repeat("9", 50) + "x"
+	path := writeTestCSV(t, "0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266,0x"+key+",100\n"+
+		"0x70997970C51812dc3A010C7d01b50e0d17dc79C8,"+long+"\n")
+
+	_, _, err := ReadAllocations(path, CSVOptions{})
+	if err == nil {
+		t.Fatal("Expected the key and the long value to be rejected as amounts")
+	}
+	if strings.Contains(err.Error(), key) || !strings.Contains(err.Error(), `amount "<redacted>"`) {
+		t.Errorf("Expected the key to be redacted, got %v", err)
+	}
+	if strings.Contains(err.Error(), long) || !strings.Contains(err.Error(), strings.Repeat("9", 42)+"…") {
+		t.Errorf("Expected the long value to be truncated, got %v", err)
+	}
+}
+
+func TestReadClaimersFromCSVWithHeader(t *testing.T) {
+	key := "0x4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318"
+	path := writeTestCSV(t, "address,private_key,amount\n0x2c7536E3605D9C16a7a3D7b1898e529396a65c23,"+key+",100\n")
+
+	claimers, err := ReadClaimersFromCSV(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(claimers) != 1 || claimers[0].PrivateKey != key || claimers[0].Amount.String() != "100" {
+		t.Errorf("Header row should not be read as a claimer, got %+v", claimers)
+	}
+
+	path = writeTestCSV(t, "0x2c7536E3605D9C16a7a3D7b1898e529396a65c23,not-a-key,100\n")
+	_, err = ReadClaimersFromCSV(path)
+	if err == nil || strings.Contains(err.Error(), "not-a-key") {
+		t.Errorf("Expected an invalid key error that does not echo the key, got %v", err)
+	}
+}
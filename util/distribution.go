@@ -0,0 +1,248 @@
+// Package util provides distribution artifact utilities
+package util
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"strings"
+
+	"merkle-generator/merkle"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// TreeConfig names the hash function and pair strategy a tree is built with
+type TreeConfig struct {
+	Hash  string `yaml:"hash" json:"hash"`
+	Pairs string `yaml:"pairs" json:"pairs"`
+}
+
+// DefaultTreeConfig returns the TokenClaimer.sol tree: keccak256 over sorted pairs
+func DefaultTreeConfig() TreeConfig {
+	return TreeConfig{
+		Hash:  "keccak256",
+		Pairs: merkle.SortedPairs.String(),
+	}
+}
+
+// Normalized fills unset fields with the defaults and spells the names the way artifacts record
+// them, since names are accepted in any case
+func (tc TreeConfig) Normalized() (TreeConfig, error) {
+	defaults := DefaultTreeConfig()
+	if tc.Hash == "" {
+		tc.Hash = defaults.Hash
+	}
+	if tc.Pairs == "" {
+		tc.Pairs = defaults.Pairs
+	}
+
+	if _, err := merkle.HashFuncByName(tc.Hash); err != nil {
+		return TreeConfig{}, err
+	}
+	pairs, err := merkle.PairStrategyByName(tc.Pairs)
+	if err != nil {
+		return TreeConfig{}, err
+	}
+	return TreeConfig{Hash: strings.ToLower(tc.Hash), Pairs: pairs.String()}, nil
+}
+
+// Options converts the config into merkle tree options, using the defaults for unset fields
+func (tc TreeConfig) Options() ([]merkle.Option, error) {
+	tc, err := tc.Normalized()
+	if err != nil {
+		return nil, err
+	}
+
+	hashFunc, err := merkle.HashFuncByName(tc.Hash)
+	if err != nil {
+		return nil, err
+	}
+	pairs, err := merkle.PairStrategyByName(tc.Pairs)
+	if err != nil {
+		return nil, err
+	}
+
+	return []merkle.Option{merkle.WithHashFunc(hashFunc), merkle.WithPairStrategy(pairs)}, nil
+}
+
+// Distribution is a merkle tree built from an allocation list, ready to be written as an artifact
+type Distribution struct {
+	TestCases   []TestCase
+	MerkleData  *MerkleData
+	Tree        TreeConfig
+	TotalAmount *big.Int
+}
+
+// DistributionEntry is one recipient in a distribution artifact
+type DistributionEntry struct {
+	Index   int               `json:"index"`
+	Address common.Address    `json:"address"`
+	Amount  string            `json:"amount"`
+	Extra   map[string]string `json:"extra,omitempty"`
+	Leaf    common.Hash       `json:"leaf"`
+	Proof   []common.Hash     `json:"proof"`
+}
+
+// DistributionArtifact is the JSON file written by Distribution.WriteArtifact
+type DistributionArtifact struct {
+	Root         common.Hash         `json:"root"`
+	Tree         TreeConfig          `json:"tree"`
+	LeafEncoding LeafConfig          `json:"leafEncoding"`
+	TotalAmount  string              `json:"totalAmount"`
+	EntryCount   int                 `json:"entryCount"`
+	Entries      []DistributionEntry `json:"entries"`
+}
+
+// BuildDistribution builds the merkle tree for an allocation list
+func BuildDistribution(testCases []TestCase, encoder *LeafEncoder, treeConfig TreeConfig) (*Distribution, error) {
+	// The artifact and shard manifests record the canonical names
+	treeConfig, err := treeConfig.Normalized()
+	if err != nil {
+		return nil, fmt.Errorf("invalid tree configuration: %w", err)
+	}
+	opts, err := treeConfig.Options()
+	if err != nil {
+		return nil, fmt.Errorf("invalid tree configuration: %w", err)
+	}
+
+	merkleData, err := GenerateLocalMerkleDataWithEncoder(testCases, encoder, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	totalAmount := new(big.Int)
+	for _, testCase := range testCases {
+		totalAmount.Add(totalAmount, testCase.Amount)
+	}
+
+	return &Distribution{
+		TestCases:   testCases,
+		MerkleData:  merkleData,
+		Tree:        treeConfig,
+		TotalAmount: totalAmount,
+	}, nil
+}
+
+// Entry returns the artifact entry, including the proof, for the recipient at the given index
+func (d *Distribution) Entry(index int) (DistributionEntry, error) {
+	proof, err := d.MerkleData.GenerateLocalProof(index)
+	if err != nil {
+		return DistributionEntry{}, err
+	}
+
+	testCase := d.TestCases[index]
+	return DistributionEntry{
+		Index:   index,
+		Address: testCase.Address,
+		Amount:  testCase.Amount.String(),
+		Extra:   testCase.Extra,
+		Leaf:    d.MerkleData.Leaves[index],
+		Proof:   proof,
+	}, nil
+}
+
+// Artifact returns the distribution artifact in memory, with every entry's proof
+func (d *Distribution) Artifact() (*DistributionArtifact, error) {
+	entries := make([]DistributionEntry, len(d.TestCases))
+	for i := range d.TestCases {
+		entry, err := d.Entry(i)
+		if err != nil {
+			return nil, err
+		}
+		entries[i] = entry
+	}
+
+	return &DistributionArtifact{
+		Root:         d.MerkleData.Root,
+		Tree:         d.Tree,
+		LeafEncoding: d.MerkleData.Encoder.Config(),
+		TotalAmount:  d.TotalAmount.String(),
+		EntryCount:   len(entries),
+		Entries:      entries,
+	}, nil
+}
+
+// WriteArtifact writes the distribution as a DistributionArtifact JSON document
+// Entries are generated and written one at a time, so the whole artifact never has to fit in memory
+func (d *Distribution) WriteArtifact(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+
+	header := []struct {
+		key   string
+		value interface{}
+	}{
+		{"root", d.MerkleData.Root},
+		{"tree", d.Tree},
+		{"leafEncoding", d.MerkleData.Encoder.Config()},
+		{"totalAmount", d.TotalAmount.String()},
+		{"entryCount", len(d.TestCases)},
+	}
+
+	bw.WriteString("{\n")
+	for _, field := range header {
+		value, err := json.Marshal(field.value)
+		if err != nil {
+			return fmt.Errorf("failed to encode %s: %w", field.key, err)
+		}
+		fmt.Fprintf(bw, "  %q: %s,\n", field.key, value)
+	}
+
+	bw.WriteString("  \"entries\": [")
+	for i := range d.TestCases {
+		entry, err := d.Entry(i)
+		if err != nil {
+			return err
+		}
+		value, err := json.Marshal(entry)
+		if err != nil {
+			return fmt.Errorf("failed to encode entry %d: %w", i, err)
+		}
+		if i > 0 {
+			bw.WriteString(",")
+		}
+		bw.WriteString("\n    ")
+		bw.Write(value)
+	}
+	bw.WriteString("\n  ]\n}\n")
+
+	return bw.Flush()
+}
+
+// WriteArtifactFile writes the distribution artifact to a file
+func (d *Distribution) WriteArtifactFile(filePath string) error {
+	file, err := os.Create(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to create artifact file: %w", err)
+	}
+
+	if err := d.WriteArtifact(file); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write artifact: %w", err)
+	}
+
+	return file.Close()
+}
+
+// ReadDistributionArtifact reads a distribution artifact written by WriteArtifact
+func ReadDistributionArtifact(filePath string) (*DistributionArtifact, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open artifact file: %w", err)
+	}
+	defer file.Close()
+
+	var artifact DistributionArtifact
+	if err := json.NewDecoder(bufio.NewReader(file)).Decode(&artifact); err != nil {
+		return nil, fmt.Errorf("failed to parse artifact file: %w", err)
+	}
+
+	if artifact.EntryCount != len(artifact.Entries) {
+		return nil, fmt.Errorf("artifact lists %d entries but entryCount is %d", len(artifact.Entries), artifact.EntryCount)
+	}
+
+	return &artifact, nil
+}
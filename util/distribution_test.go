@@ -0,0 +1,82 @@
+package util
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"merkle-generator/merkle"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func distributionTestCases() []TestCase {
+	return []TestCase{
+		{Name: "Alice", Address: common.HexToAddress("0x742d35Cc6634C0532925a3b8D4C9db96C4b4d8b6"), Amount: big.NewInt(100)},
+		{Name: "Bob", Address: common.HexToAddress("0x1111111111111111111111111111111111111111"), Amount: big.NewInt(200)},
+		{Name: "Charlie", Address: common.HexToAddress("0x2222222222222222222222222222222222222222"), Amount: big.NewInt(300)},
+	}
+}
+
+func TestDistributionArtifactRoundTrip(t *testing.T) {
+	distribution, err := BuildDistribution(distributionTestCases(), DefaultLeafEncoder(), TreeConfig{})
+	if err != nil {
+		t.Fatalf("Failed to build distribution: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := distribution.WriteArtifact(&buf); err != nil {
+		t.Fatalf("Failed to write artifact: %v", err)
+	}
+
+	var artifact DistributionArtifact
+	if err := json.Unmarshal(buf.Bytes(), &artifact); err != nil {
+		t.Fatalf("Artifact is not valid JSON: %v", err)
+	}
+
+	if artifact.Root != distribution.MerkleData.Root {
+		t.Error("Artifact root should match the tree root")
+	}
+	if artifact.EntryCount != 3 || len(artifact.Entries) != 3 {
+		t.Errorf("Expected 3 entries, got %d (%d listed)", artifact.EntryCount, len(artifact.Entries))
+	}
+	if artifact.TotalAmount != "600" {
+		t.Errorf("Expected total amount 600, got %s", artifact.TotalAmount)
+	}
+	if artifact.Tree != DefaultTreeConfig() || artifact.LeafEncoding != DefaultLeafConfig() {
+		t.Errorf("Artifact should record the default tree and leaf configuration, got %+v %+v", artifact.Tree, artifact.LeafEncoding)
+	}
+
+	for _, entry := range artifact.Entries {
+		amount, _ := new(big.Int).SetString(entry.Amount, 10)
+		leaf := merkle.HashAddressAmount(entry.Address, amount)
+		if leaf != entry.Leaf {
+			t.Errorf("Entry %d leaf does not match its address and amount", entry.Index)
+		}
+		if !merkle.VerifyProof(entry.Proof, artifact.Root, entry.Leaf) {
+			t.Errorf("Entry %d proof should verify", entry.Index)
+		}
+	}
+}
+
+func TestTreeConfigOptions(t *testing.T) {
+	if _, err := (TreeConfig{Hash: "sha256", Pairs: "positional"}).Options(); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if _, err := (TreeConfig{Hash: "md5"}).Options(); err == nil {
+		t.Error("Expected error for unknown hash function")
+	}
+	if _, err := (TreeConfig{Pairs: "random"}).Options(); err == nil {
+		t.Error("Expected error for unknown pair strategy")
+	}
+
+	// Names are accepted in any case but recorded the canonical way
+	distribution, err := BuildDistribution(distributionTestCases(), DefaultLeafEncoder(), TreeConfig{Hash: "SHA256", Pairs: "Positional"})
+	if err != nil {
+		t.Fatalf("Failed to build distribution: %v", err)
+	}
+	if want := (TreeConfig{Hash: "sha256", Pairs: "positional"}); distribution.Tree != want {
+		t.Errorf("Expected tree config %+v, got %+v", want, distribution.Tree)
+	}
+}
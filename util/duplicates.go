@@ -0,0 +1,254 @@
+// Package util provides duplicate address handling for allocation lists
+package util
+
+import (
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// DuplicatePolicy decides what happens to an address listed more than once
+// Proof lookups by address only find the first entry, so every later entry would be unclaimable.
+type DuplicatePolicy int
+
+const (
+	// DuplicateError rejects the list
+	DuplicateError DuplicatePolicy = iota
+	// DuplicateSum keeps one entry with the sum of the amounts
+	DuplicateSum
+	// DuplicateFirst keeps the first entry
+	DuplicateFirst
+	// DuplicateLast keeps the last entry
+	DuplicateLast
+)
+
+// String returns the policy name
+func (p DuplicatePolicy) String() string {
+	switch p {
+	case DuplicateError:
+		return "error"
+	case DuplicateSum:
+		return "sum"
+	case DuplicateFirst:
+		return "first"
+	case DuplicateLast:
+		return "last"
+	default:
+		return fmt.Sprintf("DuplicatePolicy(%d)", int(p))
+	}
+}
+
+// DuplicatePolicyByName returns the policy for a name, "error" if the name is empty
+func DuplicatePolicyByName(name string) (DuplicatePolicy, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", "error":
+		return DuplicateError, nil
+	case "sum":
+		return DuplicateSum, nil
+	case "first":
+		return DuplicateFirst, nil
+	case "last":
+		return DuplicateLast, nil
+	default:
+		return DuplicateError, fmt.Errorf("unknown duplicate policy %q, expected error, sum, first or last", name)
+	}
+}
+
+// DuplicateAddress describes an address listed more than once and what the policy did with it
+type DuplicateAddress struct {
+	Address common.Address
+	// Entries are the positions of the address in the input, Lines their CSV lines when known
+	Entries []int
+	Lines   []int
+	Amounts []*big.Int
+	// Kept is the amount left in the list; Lost is the listed total minus Kept, and
+	// Gained is Kept minus the first amount, the only one a lookup by address would have found
+	Kept   *big.Int
+	Lost   *big.Int
+	Gained *big.Int
+}
+
+func (d DuplicateAddress) String() string {
+	positions := d.Lines
+	label := "lines"
+	if len(positions) == 0 {
+		positions = d.Entries
+		label = "entries"
+	}
+	places := make([]string, len(positions))
+	amounts := make([]string, len(d.Amounts))
+	for i := range positions {
+		places[i] = fmt.Sprint(positions[i])
+		amounts[i] = d.Amounts[i].String()
+	}
+
+	s := fmt.Sprintf("%s on %s %s with amounts %s", d.Address.Hex(), label, strings.Join(places, ", "), strings.Join(amounts, ", "))
+	if d.Kept != nil {
+		s += fmt.Sprintf(": keeps %s, loses %s of the listed total, %+d against the first row", d.Kept, d.Lost, d.Gained)
+	}
+	return s
+}
+
+// DuplicateReport lists the duplicated addresses of an allocation list
+type DuplicateReport struct {
+	Policy    DuplicatePolicy
+	Addresses []DuplicateAddress
+}
+
+// Write prints one line per duplicated address
+func (r *DuplicateReport) Write(w io.Writer) {
+	if r == nil || len(r.Addresses) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "Duplicate addresses (policy %s): %d\n", r.Policy, len(r.Addresses))
+	for _, duplicate := range r.Addresses {
+		fmt.Fprintf(w, "  %s\n", duplicate)
+	}
+}
+
+// DuplicateAddressError rejects a list with duplicated addresses under DuplicateError
+type DuplicateAddressError struct {
+	Report *DuplicateReport
+}
+
+func (e *DuplicateAddressError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "duplicate addresses: %d, choose a duplicate policy (sum, first or last):", len(e.Report.Addresses))
+	for _, duplicate := range e.Report.Addresses {
+		b.WriteString("\n  ")
+		b.WriteString(duplicate.String())
+	}
+	return b.String()
+}
+
+// ApplyDuplicatePolicy returns the list with one entry per address and a report of every duplicated address.
+// Kept entries stay in input order; under DuplicateLast an address moves to its last position.
+// Summed entries must agree on their extra columns.
+func ApplyDuplicatePolicy(testCases []TestCase, policy DuplicatePolicy) ([]TestCase, *DuplicateReport, error) {
+	entries := make([]duplicateEntry, len(testCases))
+	for i, testCase := range testCases {
+		entries[i] = duplicateEntry{
+			address:  testCase.Address,
+			amount:   testCase.Amount,
+			extra:    testCase.Extra,
+			location: fmt.Sprintf("entry %d", i),
+		}
+	}
+
+	kept, report, err := resolveDuplicates(entries, policy)
+	if err != nil {
+		return nil, report, err
+	}
+	if kept == nil {
+		return testCases, report, nil
+	}
+
+	result := make([]TestCase, len(kept))
+	for i, k := range kept {
+		result[i] = testCases[k.index]
+		result[i].Amount = k.amount
+	}
+	return result, report, nil
+}
+
+// duplicateEntry is what the policy looks at in an entry
+type duplicateEntry struct {
+	address  common.Address
+	amount   *big.Int
+	extra    map[string]string
+	location string
+}
+
+// keptEntry is an entry left by the policy, with its possibly summed amount
+type keptEntry struct {
+	index  int
+	amount *big.Int
+}
+
+// resolveDuplicates applies the policy; kept is nil when no address is duplicated
+func resolveDuplicates(entries []duplicateEntry, policy DuplicatePolicy) ([]keptEntry, *DuplicateReport, error) {
+	if policy < DuplicateError || policy > DuplicateLast {
+		return nil, nil, fmt.Errorf("unknown duplicate policy %s", policy)
+	}
+
+	positions := make(map[common.Address][]int, len(entries))
+	var order []common.Address
+	for i, entry := range entries {
+		if _, exists := positions[entry.address]; !exists {
+			order = append(order, entry.address)
+		}
+		positions[entry.address] = append(positions[entry.address], i)
+	}
+
+	report := &DuplicateReport{Policy: policy}
+	if len(order) == len(entries) {
+		return nil, report, nil
+	}
+
+	keep := make(map[int]*big.Int, len(order))
+	for _, address := range order {
+		indices := positions[address]
+		if len(indices) == 1 {
+			keep[indices[0]] = entries[indices[0]].amount
+			continue
+		}
+
+		duplicate := DuplicateAddress{Address: address, Entries: indices}
+		listed := new(big.Int)
+		for _, index := range indices {
+			duplicate.Amounts = append(duplicate.Amounts, entries[index].amount)
+			listed.Add(listed, entries[index].amount)
+		}
+
+		first := indices[0]
+		position, amount := first, entries[first].amount
+		switch policy {
+		case DuplicateSum:
+			for _, index := range indices[1:] {
+				if !sameExtra(entries[first].extra, entries[index].extra) {
+					return nil, nil, fmt.Errorf("%s and %s for %s have different extra columns and cannot be summed",
+						entries[first].location, entries[index].location, address.Hex())
+				}
+			}
+			amount = listed
+		case DuplicateLast:
+			position = indices[len(indices)-1]
+			amount = entries[position].amount
+		}
+
+		if policy != DuplicateError {
+			duplicate.Kept = amount
+			duplicate.Lost = new(big.Int).Sub(listed, amount)
+			duplicate.Gained = new(big.Int).Sub(amount, entries[first].amount)
+			keep[position] = amount
+		}
+		report.Addresses = append(report.Addresses, duplicate)
+	}
+
+	if policy == DuplicateError {
+		return nil, report, &DuplicateAddressError{Report: report}
+	}
+
+	kept := make([]keptEntry, 0, len(order))
+	for i := range entries {
+		if amount, ok := keep[i]; ok {
+			kept = append(kept, keptEntry{index: i, amount: amount})
+		}
+	}
+	return kept, report, nil
+}
+
+func sameExtra(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for key, value := range a {
+		if other, ok := b[key]; !ok || other != value {
+			return false
+		}
+	}
+	return true
+}
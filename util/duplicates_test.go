@@ -0,0 +1,114 @@
+package util
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func duplicateTestCases() []TestCase {
+	alice := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	bob := common.HexToAddress("0x2222222222222222222222222222222222222222")
+	return []TestCase{
+		{Address: alice, Amount: big.NewInt(100)},
+		{Address: bob, Amount: big.NewInt(200)},
+		{Address: alice, Amount: big.NewInt(50)},
+	}
+}
+
+func TestApplyDuplicatePolicy(t *testing.T) {
+	cases := []struct {
+		policy  DuplicatePolicy
+		amounts []int64
+		kept    int64
+		lost    int64
+		gained  int64
+	}{
+		{DuplicateSum, []int64{150, 200}, 150, 0, 50},
+		{DuplicateFirst, []int64{100, 200}, 100, 50, 0},
+		// Under the last policy the address moves to its last row
+		{DuplicateLast, []int64{200, 50}, 50, 100, -50},
+	}
+
+	for _, c := range cases {
+		testCases, report, err := ApplyDuplicatePolicy(duplicateTestCases(), c.policy)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", c.policy, err)
+		}
+		if len(testCases) != len(c.amounts) {
+			t.Fatalf("%s: expected %d entries, got %d", c.policy, len(c.amounts), len(testCases))
+		}
+		for i, amount := range c.amounts {
+			if testCases[i].Amount.Int64() != amount {
+				t.Errorf("%s: entry %d: expected amount %d, got %s", c.policy, i, amount, testCases[i].Amount)
+			}
+		}
+
+		if len(report.Addresses) != 1 {
+			t.Fatalf("%s: expected one duplicated address, got %d", c.policy, len(report.Addresses))
+		}
+		duplicate := report.Addresses[0]
+		if duplicate.Kept.Int64() != c.kept || duplicate.Lost.Int64() != c.lost || duplicate.Gained.Int64() != c.gained {
+			t.Errorf("%s: unexpected report %s", c.policy, duplicate)
+		}
+	}
+
+	// The input is not modified by summing
+	input := duplicateTestCases()
+	ApplyDuplicatePolicy(input, DuplicateSum)
+	if input[0].Amount.Int64() != 100 {
+		t.Errorf("Summing should not modify the input, got %s", input[0].Amount)
+	}
+}
+
+func TestApplyDuplicatePolicyErrors(t *testing.T) {
+	_, report, err := ApplyDuplicatePolicy(duplicateTestCases(), DuplicateError)
+	var duplicateErr *DuplicateAddressError
+	if !errors.As(err, &duplicateErr) || len(report.Addresses) != 1 || report.Addresses[0].Kept != nil {
+		t.Errorf("Expected a DuplicateAddressError listing the address, got %v", err)
+	}
+
+	testCases := duplicateTestCases()
+	testCases[0].Extra = map[string]string{"tokenId": "1"}
+	testCases[2].Extra = map[string]string{"tokenId": "2"}
+	if _, _, err := ApplyDuplicatePolicy(testCases, DuplicateSum); err == nil {
+		t.Error("Expected an error summing entries with different extra columns")
+	}
+
+	unique := duplicateTestCases()[:2]
+	kept, report, err := ApplyDuplicatePolicy(unique, DuplicateError)
+	if err != nil || len(kept) != 2 || len(report.Addresses) != 0 {
+		t.Errorf("A list without duplicates should pass unchanged, got %v", err)
+	}
+
+	if _, err := DuplicatePolicyByName("merge"); err == nil {
+		t.Error("Expected an error for an unknown policy name")
+	}
+}
+
+func TestReadAllocationsDuplicates(t *testing.T) {
+	path := writeTestCSV(t, strings.Join([]string{
+		"address,amount",
+		"0x1111111111111111111111111111111111111111,100",
+		"0x2222222222222222222222222222222222222222,200",
+		"0x1111111111111111111111111111111111111111,50",
+	}, "\n"))
+
+	if _, _, err := ReadAllocations(path, CSVOptions{}); err == nil || !strings.Contains(err.Error(), "lines 2, 4") {
+		t.Errorf("Expected duplicates to be rejected by line, got %v", err)
+	}
+
+	testCases, report, err := ReadAllocations(path, CSVOptions{Duplicates: DuplicateSum})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(testCases) != 2 || testCases[0].Amount.Int64() != 150 || testCases[1].Name != "Claimer_2" {
+		t.Errorf("Expected the rows of 0x1111... to be summed, got %+v", testCases)
+	}
+	if lines := report.Duplicates.Addresses[0].Lines; len(lines) != 2 || lines[0] != 2 || lines[1] != 4 {
+		t.Errorf("Expected lines 2 and 4 in the report, got %v", lines)
+	}
+}
@@ -0,0 +1,85 @@
+// Package util provides ERC-20 token utilities
+package util
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// ERC20ABI contains the parts of the ERC-20 ABI used by the tools
+const ERC20ABI = `[
+	{
+		"inputs": [],
+		"name": "decimals",
+		"outputs": [{"internalType": "uint8", "name": "", "type": "uint8"}],
+		"stateMutability": "view",
+		"type": "function"
+	},
+	{
+		"inputs": [{"internalType": "address", "name": "account", "type": "address"}],
+		"name": "balanceOf",
+		"outputs": [{"internalType": "uint256", "name": "", "type": "uint256"}],
+		"stateMutability": "view",
+		"type": "function"
+	}
+]`
+
+// TokenDecimals reads decimals() from an ERC-20 token contract
+func (ec *EthClient) TokenDecimals(token common.Address) (int, error) {
+	tokenABI, err := abi.JSON(strings.NewReader(ERC20ABI))
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse ERC-20 ABI: %w", err)
+	}
+
+	data, err := tokenABI.Pack("decimals")
+	if err != nil {
+		return 0, fmt.Errorf("failed to pack decimals call: %w", err)
+	}
+
+	result, err := ec.CallContract(context.Background(), ethereum.CallMsg{To: &token, Data: data}, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to call decimals on %s: %w", token.Hex(), err)
+	}
+	if len(result) == 0 {
+		return 0, fmt.Errorf("%s returned no data for decimals(), is it an ERC-20 token?", token.Hex())
+	}
+
+	var decimals uint8
+	if err := tokenABI.UnpackIntoInterface(&decimals, "decimals", result); err != nil {
+		return 0, fmt.Errorf("failed to unpack decimals result: %w", err)
+	}
+	return int(decimals), nil
+}
+
+// TokenBalance reads balanceOf(holder) from an ERC-20 token contract
+func (ec *EthClient) TokenBalance(token, holder common.Address) (*big.Int, error) {
+	tokenABI, err := abi.JSON(strings.NewReader(ERC20ABI))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ERC-20 ABI: %w", err)
+	}
+
+	data, err := tokenABI.Pack("balanceOf", holder)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pack balanceOf call: %w", err)
+	}
+
+	result, err := ec.CallContract(context.Background(), ethereum.CallMsg{To: &token, Data: data}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call balanceOf on %s: %w", token.Hex(), err)
+	}
+	if len(result) == 0 {
+		return nil, fmt.Errorf("%s returned no data for balanceOf(), is it an ERC-20 token?", token.Hex())
+	}
+
+	var balance *big.Int
+	if err := tokenABI.UnpackIntoInterface(&balance, "balanceOf", result); err != nil {
+		return nil, fmt.Errorf("failed to unpack balanceOf result: %w", err)
+	}
+	return balance, nil
+}
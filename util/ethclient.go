@@ -6,26 +6,53 @@ import (
 	"crypto/ecdsa"
 	"fmt"
 	"math/big"
+	"net/http"
+	"strings"
 
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
-	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
 )
 
 // EthClient wraps ethclient.Client with additional utilities
 type EthClient struct {
 	*ethclient.Client
 	ChainID *big.Int
+	// Fees controls transaction pricing; the zero value picks dynamic fees on London chains
+	Fees FeeOptions
+	// Nonces, when set, hands out local nonces instead of asking the node for every transaction
+	Nonces *NonceManager
 }
 
 // NewEthClient creates a new EthClient with chain ID cached
 func NewEthClient(rpcURL string) (*EthClient, error) {
-	client, err := ethclient.Dial(rpcURL)
+	return NewEthClientWithOptions(rpcURL, ClientOptions{})
+}
+
+// ClientOptions controls how NewEthClientWithOptions connects
+type ClientOptions struct {
+	// RequestsPerSecond limits RPC requests to an HTTP endpoint; zero means no limit
+	RequestsPerSecond float64
+}
+
+// NewEthClientWithOptions creates a new EthClient with chain ID cached
+func NewEthClientWithOptions(rpcURL string, opts ClientOptions) (*EthClient, error) {
+	var rpcOpts []rpc.ClientOption
+	if limiter := NewRateLimiter(opts.RequestsPerSecond); limiter != nil {
+		if !strings.HasPrefix(rpcURL, "http://") && !strings.HasPrefix(rpcURL, "https://") {
+			return nil, fmt.Errorf("a requests per second limit needs an http(s) RPC endpoint")
+		}
+		transport := &rateLimitedTransport{base: http.DefaultTransport, limiter: limiter}
+		rpcOpts = append(rpcOpts, rpc.WithHTTPClient(&http.Client{Transport: transport}))
+	}
+
+	rpcClient, err := rpc.DialOptions(context.Background(), rpcURL, rpcOpts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to Ethereum node: %w", err)
 	}
+	client := ethclient.NewClient(rpcClient)
 
 	// Get and cache chain ID
 	chainID, err := client.ChainID(context.Background())
@@ -42,59 +69,105 @@ func NewEthClient(rpcURL string) (*EthClient, error) {
 
 // AccountInfo contains account-related information
 type AccountInfo struct {
-	Address    common.Address
+	Address common.Address
+	Signer  Signer
+	// PrivateKey is only set for accounts created from a private key with GetAccountInfoForKey
 	PrivateKey *ecdsa.PrivateKey
 	Nonce      uint64
 }
 
-// GetAccountInfo derives account information from a private key
+// GetAccountInfo derives account information from a hex private key
 func (ec *EthClient) GetAccountInfo(privateKeyHex string) (*AccountInfo, error) {
 	// Parse private key
-	privateKey, err := crypto.HexToECDSA(removeHexPrefix(privateKeyHex))
+	privateKey, err := parsePrivateKey(privateKeyHex)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse private key: %w", err)
 	}
 
-	// Get the public key and derive the address
-	publicKey := privateKey.Public()
-	publicKeyECDSA, ok := publicKey.(*ecdsa.PublicKey)
-	if !ok {
-		return nil, fmt.Errorf("error casting public key to ECDSA")
+	return ec.GetAccountInfoForKey(privateKey)
+}
+
+// GetAccountInfoForKey derives account information from a parsed private key
+func (ec *EthClient) GetAccountInfoForKey(privateKey *ecdsa.PrivateKey) (*AccountInfo, error) {
+	account, err := ec.GetAccountInfoForSigner(NewKeySigner(privateKey))
+	if err != nil {
+		return nil, err
 	}
-	address := crypto.PubkeyToAddress(*publicKeyECDSA)
+	account.PrivateKey = privateKey
+	return account, nil
+}
+
+// GetAccountInfoForSigner looks up the pending nonce of a signer's address, e.g. one from
+// NewKeystoreSigner or NewMnemonicSigner. With Nonces set the nonce is reserved from it instead.
+func (ec *EthClient) GetAccountInfoForSigner(signer Signer) (*AccountInfo, error) {
+	address := signer.Address()
 
 	// Get nonce
-	nonce, err := ec.PendingNonceAt(context.Background(), address)
+	var nonce uint64
+	var err error
+	if ec.Nonces != nil {
+		nonce, err = ec.Nonces.Next(context.Background(), address)
+	} else {
+		nonce, err = ec.PendingNonceAt(context.Background(), address)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get nonce: %w", err)
 	}
 
 	return &AccountInfo{
-		Address:    address,
-		PrivateKey: privateKey,
-		Nonce:      nonce,
+		Address: address,
+		Signer:  signer,
+		Nonce:   nonce,
 	}, nil
 }
 
 // TransactionParams contains parameters for creating transactions
+// Setting GasPrice creates a legacy transaction; setting GasTipCap and GasFeeCap a dynamic fee one.
+// When neither is set the fees come from SuggestFees.
 type TransactionParams struct {
-	To       common.Address
-	Data     []byte
-	Value    *big.Int
-	GasLimit uint64
-	GasPrice *big.Int
+	To        common.Address
+	Data      []byte
+	Value     *big.Int
+	GasLimit  uint64
+	GasPrice  *big.Int
+	GasTipCap *big.Int
+	GasFeeCap *big.Int
 }
 
 // EstimateAndCreateTransaction estimates gas and creates a transaction
 func (ec *EthClient) EstimateAndCreateTransaction(account *AccountInfo, params TransactionParams) (*types.Transaction, error) {
+	// Get fees if not provided
+	var fees TxFees
+	switch {
+	case params.GasPrice != nil:
+		fees.GasPrice = params.GasPrice
+	case params.GasTipCap != nil && params.GasFeeCap != nil:
+		fees.GasTipCap, fees.GasFeeCap = params.GasTipCap, params.GasFeeCap
+	case params.GasTipCap != nil || params.GasFeeCap != nil:
+		return nil, fmt.Errorf("set both GasTipCap and GasFeeCap for a dynamic fee transaction")
+	default:
+		suggested, err := ec.SuggestFees(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		fees = suggested
+	}
+
+	// Set default value if not provided
+	if params.Value == nil {
+		params.Value = big.NewInt(0)
+	}
+
 	// Estimate gas if not provided
 	if params.GasLimit == 0 {
 		gasLimit, err := ec.EstimateGas(context.Background(), ethereum.CallMsg{
-			From:     account.Address,
-			To:       &params.To,
-			Data:     params.Data,
-			Value:    params.Value,
-			GasPrice: params.GasPrice,
+			From:      account.Address,
+			To:        &params.To,
+			Data:      params.Data,
+			Value:     params.Value,
+			GasPrice:  fees.GasPrice,
+			GasTipCap: fees.GasTipCap,
+			GasFeeCap: fees.GasFeeCap,
 		})
 		if err != nil {
 			// Use default gas limit if estimation fails
@@ -104,41 +177,79 @@ func (ec *EthClient) EstimateAndCreateTransaction(account *AccountInfo, params T
 		}
 	}
 
-	// Get gas price if not provided
-	if params.GasPrice == nil {
-		gasPrice, err := ec.SuggestGasPrice(context.Background())
-		if err != nil {
-			return nil, fmt.Errorf("failed to get gas price: %w", err)
-		}
-		params.GasPrice = gasPrice
-	}
-
-	// Set default value if not provided
-	if params.Value == nil {
-		params.Value = big.NewInt(0)
-	}
-
 	// Create transaction
-	tx := types.NewTransaction(account.Nonce, params.To, params.Value, params.GasLimit, params.GasPrice, params.Data)
-
-	return tx, nil
+	if fees.Dynamic() {
+		return types.NewTx(&types.DynamicFeeTx{
+			ChainID:   ec.ChainID,
+			Nonce:     account.Nonce,
+			GasTipCap: fees.GasTipCap,
+			GasFeeCap: fees.GasFeeCap,
+			Gas:       params.GasLimit,
+			To:        &params.To,
+			Value:     params.Value,
+			Data:      params.Data,
+		}), nil
+	}
+	return types.NewTransaction(account.Nonce, params.To, params.Value, params.GasLimit, fees.GasPrice, params.Data), nil
 }
 
 // SignAndSendTransaction signs a transaction and sends it to the network
 func (ec *EthClient) SignAndSendTransaction(tx *types.Transaction, privateKey *ecdsa.PrivateKey) (*types.Transaction, error) {
-	// Sign the transaction
-	signedTx, err := types.SignTx(tx, types.NewEIP155Signer(ec.ChainID), privateKey)
+	return ec.SignAndSendTransactionWithSigner(tx, NewKeySigner(privateKey))
+}
+
+// SignAndSendTransactionWithSigner signs a transaction with signer and sends it to the network
+func (ec *EthClient) SignAndSendTransactionWithSigner(tx *types.Transaction, signer Signer) (*types.Transaction, error) {
+	signedTx, err := ec.SignTransaction(tx, signer)
+	if err != nil {
+		return nil, err
+	}
+	if err := ec.SendSignedTransaction(signedTx, signer.Address()); err != nil {
+		return nil, err
+	}
+	return signedTx, nil
+}
+
+// SignTransaction signs a transaction for the client's chain, so its hash is known before sending
+// With Nonces set, a failed signature hands the nonce back.
+func (ec *EthClient) SignTransaction(tx *types.Transaction, signer Signer) (*types.Transaction, error) {
+	signedTx, err := signer.SignTx(tx, ec.ChainID)
 	if err != nil {
+		ec.ReleaseNonce(signer.Address(), tx.Nonce())
 		return nil, fmt.Errorf("failed to sign transaction: %w", err)
 	}
+	return signedTx, nil
+}
 
-	// Send the transaction
-	err = ec.SendTransaction(context.Background(), signedTx)
+// SendSignedTransaction sends a transaction signed by from to the network
+// With Nonces set, the nonce is settled by the outcome: a send the node took, or already has ("already
+// known"), uses it; "nonce too low" or "nonce too high" uses it up and resyncs the sender; any other
+// failure hands it back.
+func (ec *EthClient) SendSignedTransaction(signedTx *types.Transaction, from common.Address) error {
+	err := ec.SendTransaction(context.Background(), signedTx)
+	if ec.Nonces != nil {
+		switch {
+		case err == nil || IsAlreadyKnown(err):
+			ec.Nonces.Sent(from, signedTx.Nonce())
+		case IsNonceError(err):
+			ec.Nonces.Sent(from, signedTx.Nonce())
+			ec.Nonces.Resync(from)
+		default:
+			ec.Nonces.Release(from, signedTx.Nonce())
+		}
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to send transaction: %w", err)
+		return fmt.Errorf("failed to send transaction: %w", err)
 	}
+	return nil
+}
 
-	return signedTx, nil
+// ReleaseNonce hands back a nonce from GetAccountInfoForSigner that won't be sent; it does nothing
+// without Nonces
+func (ec *EthClient) ReleaseNonce(address common.Address, nonce uint64) {
+	if ec.Nonces != nil {
+		ec.Nonces.Release(address, nonce)
+	}
 }
 
 // removeHexPrefix removes the 0x prefix from hex strings
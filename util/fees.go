@@ -0,0 +1,207 @@
+// Package util provides transaction fee selection for EIP-1559 and legacy chains
+package util
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+// Fee modes for FeeOptions.Mode
+const (
+	// FeeModeAuto sends dynamic fee transactions when the latest block has a base fee
+	FeeModeAuto = "auto"
+	// FeeModeDynamic always sends EIP-1559 dynamic fee transactions
+	FeeModeDynamic = "dynamic"
+	// FeeModeLegacy always sends legacy transactions with a single gas price
+	FeeModeLegacy = "legacy"
+)
+
+// Default fee multipliers: a max fee of twice the base fee survives six full blocks of base fee growth
+const (
+	DefaultBaseFeeMultiplier = 2.0
+	DefaultTipMultiplier     = 1.0
+)
+
+// FeeOptions controls how EstimateAndCreateTransaction prices transactions
+// The zero value is auto mode with the default multipliers and no caps.
+type FeeOptions struct {
+	Mode string
+	// BaseFeeMultiplier scales the latest base fee into the max fee, before the tip is added
+	BaseFeeMultiplier float64
+	// TipMultiplier scales the node's suggested tip, or its gas price in legacy mode
+	TipMultiplier float64
+	// MaxFeePerGas caps the max fee, or the gas price in legacy mode; nil means no cap
+	MaxFeePerGas *big.Int
+	// MaxPriorityFeePerGas caps the tip; nil means no cap
+	MaxPriorityFeePerGas *big.Int
+	// GasPriceCeiling makes SuggestFees fail with ErrGasPriceAboveCeiling while the current price,
+	// base fee plus tip or the legacy gas price, is above it; nil means no ceiling
+	GasPriceCeiling *big.Int
+}
+
+// ErrGasPriceAboveCeiling is returned by SuggestFees while gas is more expensive than the ceiling
+var ErrGasPriceAboveCeiling = errors.New("gas price above ceiling")
+
+// Ceiling wait defaults: the first recheck comes soon, later ones back off to a few minutes apart
+const (
+	DefaultCeilingRetry    = 15 * time.Second
+	DefaultMaxCeilingRetry = 5 * time.Minute
+)
+
+// CeilingBackoff controls WaitBelowCeiling; zero fields take the defaults
+type CeilingBackoff struct {
+	// Initial is the first wait; each following wait doubles, up to Max
+	Initial time.Duration
+	Max     time.Duration
+}
+
+// WaitBelowCeiling runs attempt until it returns anything but ErrGasPriceAboveCeiling, waiting with
+// exponential backoff in between. onWait, when set, is told about every wait before it starts.
+// A canceled ctx ends the wait with an error wrapping ctx.Err().
+func WaitBelowCeiling(ctx context.Context, backoff CeilingBackoff, attempt func() error, onWait func(err error, delay time.Duration)) error {
+	if backoff.Initial <= 0 {
+		backoff.Initial = DefaultCeilingRetry
+	}
+	if backoff.Max <= 0 {
+		backoff.Max = DefaultMaxCeilingRetry
+	}
+
+	delay := backoff.Initial
+	for {
+		err := attempt()
+		if !errors.Is(err, ErrGasPriceAboveCeiling) {
+			return err
+		}
+		if delay > backoff.Max {
+			delay = backoff.Max
+		}
+		if onWait != nil {
+			onWait(err, delay)
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("stopped waiting for gas below the ceiling: %w", ctx.Err())
+		case <-timer.C:
+		}
+		delay *= 2
+	}
+}
+
+// TxFees are the prices of one transaction: GasPrice for legacy, GasTipCap and GasFeeCap otherwise
+type TxFees struct {
+	GasPrice  *big.Int
+	GasTipCap *big.Int
+	GasFeeCap *big.Int
+}
+
+// Dynamic reports whether the fees are for an EIP-1559 transaction
+func (f TxFees) Dynamic() bool {
+	return f.GasFeeCap != nil
+}
+
+// Validate checks the mode and multipliers
+func (o FeeOptions) Validate() error {
+	switch o.Mode {
+	case "", FeeModeAuto, FeeModeDynamic, FeeModeLegacy:
+	default:
+		return fmt.Errorf("unknown fee mode %q, expected %s, %s or %s", o.Mode, FeeModeAuto, FeeModeDynamic, FeeModeLegacy)
+	}
+	if o.BaseFeeMultiplier < 0 || o.TipMultiplier < 0 {
+		return fmt.Errorf("fee multipliers cannot be negative")
+	}
+	return nil
+}
+
+func (o FeeOptions) baseFeeMultiplier() float64 {
+	if o.BaseFeeMultiplier == 0 {
+		return DefaultBaseFeeMultiplier
+	}
+	return o.BaseFeeMultiplier
+}
+
+func (o FeeOptions) tipMultiplier() float64 {
+	if o.TipMultiplier == 0 {
+		return DefaultTipMultiplier
+	}
+	return o.TipMultiplier
+}
+
+// DynamicFees prices an EIP-1559 transaction from the latest base fee and the suggested tip:
+// tip = suggestedTip * TipMultiplier and maxFee = baseFee * BaseFeeMultiplier + tip, each capped.
+// A tip above the capped max fee is lowered to it.
+func (o FeeOptions) DynamicFees(baseFee, suggestedTip *big.Int) TxFees {
+	tip := capFee(mulFee(suggestedTip, o.tipMultiplier()), o.MaxPriorityFeePerGas)
+	maxFee := capFee(new(big.Int).Add(mulFee(baseFee, o.baseFeeMultiplier()), tip), o.MaxFeePerGas)
+	if tip.Cmp(maxFee) > 0 {
+		tip = new(big.Int).Set(maxFee)
+	}
+	return TxFees{GasTipCap: tip, GasFeeCap: maxFee}
+}
+
+// LegacyFees prices a legacy transaction from the suggested gas price, scaled by TipMultiplier and
+// capped by MaxFeePerGas
+func (o FeeOptions) LegacyFees(suggestedGasPrice *big.Int) TxFees {
+	return TxFees{GasPrice: capFee(mulFee(suggestedGasPrice, o.tipMultiplier()), o.MaxFeePerGas)}
+}
+
+// SuggestFees asks the node for current prices and applies the client's FeeOptions
+// In auto mode, chains whose latest block has no base fee (pre-London) get legacy fees.
+func (ec *EthClient) SuggestFees(ctx context.Context) (TxFees, error) {
+	opts := ec.Fees
+	if err := opts.Validate(); err != nil {
+		return TxFees{}, err
+	}
+
+	if opts.Mode != FeeModeLegacy {
+		header, err := ec.HeaderByNumber(ctx, nil)
+		if err != nil {
+			return TxFees{}, fmt.Errorf("failed to get latest block: %w", err)
+		}
+		switch {
+		case header.BaseFee != nil:
+			tip, err := ec.SuggestGasTipCap(ctx)
+			if err != nil {
+				return TxFees{}, fmt.Errorf("failed to get gas tip cap: %w", err)
+			}
+			fees := opts.DynamicFees(header.BaseFee, tip)
+			return fees, opts.checkCeiling(new(big.Int).Add(header.BaseFee, fees.GasTipCap))
+		case opts.Mode == FeeModeDynamic:
+			return TxFees{}, fmt.Errorf("chain has no base fee, dynamic fee transactions need London; use fee mode %s", FeeModeLegacy)
+		}
+	}
+
+	gasPrice, err := ec.SuggestGasPrice(ctx)
+	if err != nil {
+		return TxFees{}, fmt.Errorf("failed to get gas price: %w", err)
+	}
+	fees := opts.LegacyFees(gasPrice)
+	return fees, opts.checkCeiling(fees.GasPrice)
+}
+
+// checkCeiling fails when price is above GasPriceCeiling
+func (o FeeOptions) checkCeiling(price *big.Int) error {
+	if o.GasPriceCeiling != nil && price.Cmp(o.GasPriceCeiling) > 0 {
+		return fmt.Errorf("%w: %s wei is above %s wei", ErrGasPriceAboveCeiling, price, o.GasPriceCeiling)
+	}
+	return nil
+}
+
+// mulFee scales a fee in wei, rounding down
+func mulFee(fee *big.Int, multiplier float64) *big.Int {
+	scaled, _ := new(big.Float).Mul(new(big.Float).SetInt(fee), big.NewFloat(multiplier)).Int(nil)
+	return scaled
+}
+
+// capFee returns fee, or limit when fee is above it
+func capFee(fee, limit *big.Int) *big.Int {
+	if limit != nil && fee.Cmp(limit) > 0 {
+		return new(big.Int).Set(limit)
+	}
+	return fee
+}
@@ -0,0 +1,116 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestDynamicFees(t *testing.T) {
+	gwei := big.NewInt(1_000_000_000)
+	baseFee := new(big.Int).Mul(big.NewInt(20), gwei)
+	tip := new(big.Int).Mul(big.NewInt(2), gwei)
+
+	// Defaults: max fee = 2 * base fee + tip
+	fees := FeeOptions{}.DynamicFees(baseFee, tip)
+	if !fees.Dynamic() || fees.GasTipCap.Cmp(tip) != 0 || fees.GasFeeCap.Cmp(new(big.Int).Mul(big.NewInt(42), gwei)) != 0 {
+		t.Errorf("Unexpected default fees: tip %v, max fee %v", fees.GasTipCap, fees.GasFeeCap)
+	}
+
+	opts := FeeOptions{BaseFeeMultiplier: 1.5, TipMultiplier: 2}
+	fees = opts.DynamicFees(baseFee, tip)
+	if fees.GasTipCap.Cmp(new(big.Int).Mul(big.NewInt(4), gwei)) != 0 || fees.GasFeeCap.Cmp(new(big.Int).Mul(big.NewInt(34), gwei)) != 0 {
+		t.Errorf("Unexpected scaled fees: tip %v, max fee %v", fees.GasTipCap, fees.GasFeeCap)
+	}
+
+	// A max fee cap below the tip lowers the tip too
+	opts = FeeOptions{MaxFeePerGas: big.NewInt(1000), MaxPriorityFeePerGas: new(big.Int).Mul(big.NewInt(3), gwei)}
+	fees = opts.DynamicFees(baseFee, tip)
+	if fees.GasFeeCap.Int64() != 1000 || fees.GasTipCap.Int64() != 1000 {
+		t.Errorf("Expected fees capped at 1000 wei, got tip %v, max fee %v", fees.GasTipCap, fees.GasFeeCap)
+	}
+}
+
+func TestLegacyFees(t *testing.T) {
+	fees := FeeOptions{TipMultiplier: 1.5, MaxFeePerGas: big.NewInt(120)}.LegacyFees(big.NewInt(100))
+	if fees.Dynamic() || fees.GasPrice.Int64() != 120 {
+		t.Errorf("Expected a legacy gas price capped at 120, got %v", fees.GasPrice)
+	}
+	if fees := (FeeOptions{}).LegacyFees(big.NewInt(100)); fees.GasPrice.Int64() != 100 {
+		t.Errorf("Expected the suggested gas price, got %v", fees.GasPrice)
+	}
+}
+
+func TestGasPriceCeiling(t *testing.T) {
+	opts := FeeOptions{GasPriceCeiling: big.NewInt(100)}
+	if err := opts.checkCeiling(big.NewInt(100)); err != nil {
+		t.Errorf("Expected a price at the ceiling to pass, got %v", err)
+	}
+	if err := opts.checkCeiling(big.NewInt(101)); !errors.Is(err, ErrGasPriceAboveCeiling) {
+		t.Errorf("Expected ErrGasPriceAboveCeiling, got %v", err)
+	}
+	if err := (FeeOptions{}).checkCeiling(big.NewInt(1_000_000)); err != nil {
+		t.Errorf("Expected no ceiling by default, got %v", err)
+	}
+}
+
+func TestWaitBelowCeiling(t *testing.T) {
+	backoff := CeilingBackoff{Initial: time.Millisecond, Max: 3 * time.Millisecond}
+
+	// Retries with backoff until the price drops, then returns the attempt's own result
+	attempts := 0
+	var delays []time.Duration
+	err := WaitBelowCeiling(context.Background(), backoff, func() error {
+		attempts++
+		if attempts < 4 {
+			return fmt.Errorf("failed to create transaction: %w", ErrGasPriceAboveCeiling)
+		}
+		return nil
+	}, func(err error, delay time.Duration) {
+		delays = append(delays, delay)
+	})
+	if err != nil || attempts != 4 {
+		t.Fatalf("Expected success on the fourth attempt, got %v after %d", err, attempts)
+	}
+	if want := []time.Duration{time.Millisecond, 2 * time.Millisecond, 3 * time.Millisecond}; fmt.Sprint(delays) != fmt.Sprint(want) {
+		t.Errorf("Expected waits %v, got %v", want, delays)
+	}
+
+	// Other errors are returned at once
+	other := errors.New("insufficient funds")
+	if err := WaitBelowCeiling(context.Background(), backoff, func() error { return other }, nil); err != other {
+		t.Errorf("Expected the attempt's error, got %v", err)
+	}
+
+	// Canceling stops the wait
+	ctx, cancel := context.WithCancel(context.Background())
+	err = WaitBelowCeiling(ctx, CeilingBackoff{Initial: time.Hour}, func() error {
+		cancel()
+		return ErrGasPriceAboveCeiling
+	}, nil)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
+
+func TestFeesConfigOptions(t *testing.T) {
+	opts, err := FeesConfig{Mode: FeeModeDynamic, MaxFeeGwei: "30", MaxTipGwei: "1.5", CeilingGwei: "25"}.Options()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if opts.MaxFeePerGas.String() != "30000000000" || opts.MaxPriorityFeePerGas.String() != "1500000000" {
+		t.Errorf("Unexpected caps %v and %v", opts.MaxFeePerGas, opts.MaxPriorityFeePerGas)
+	}
+	if opts.GasPriceCeiling.String() != "25000000000" {
+		t.Errorf("Unexpected ceiling %v", opts.GasPriceCeiling)
+	}
+
+	for _, config := range []FeesConfig{{Mode: "eip1559"}, {TipMultiplier: -1}, {MaxFeeGwei: "ten"}} {
+		if _, err := config.Options(); err == nil {
+			t.Errorf("Expected an error for %+v", config)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+// Package util provides BIP-39 seeds and BIP-32 key derivation
+package util
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/tyler-smith/go-bip32"
+	"github.com/tyler-smith/go-bip39"
+)
+
+// DefaultHDPath is the first account of the standard Ethereum derivation path
+const DefaultHDPath = "m/44'/60'/0'/0/0"
+
+// MnemonicToSeed checks a BIP-39 mnemonic against the English wordlist and its checksum, then turns
+// it and an optional passphrase into the 64-byte seed. A mistyped word fails the checksum instead
+// of silently deriving a different account. Only ASCII passphrases are supported, since those need
+// no Unicode normalization.
+func MnemonicToSeed(mnemonic, passphrase string) ([]byte, error) {
+	words := strings.Fields(mnemonic)
+	switch len(words) {
+	case 12, 15, 18, 21, 24:
+	default:
+		return nil, fmt.Errorf("mnemonic must have 12, 15, 18, 21 or 24 words, got %d", len(words))
+	}
+	for i, word := range words {
+		if _, ok := bip39.GetWordIndex(word); !ok {
+			return nil, fmt.Errorf("mnemonic word %d is not in the BIP-39 English wordlist", i+1)
+		}
+	}
+	normalized := strings.Join(words, " ")
+	if _, err := bip39.EntropyFromMnemonic(normalized); err != nil {
+		if errors.Is(err, bip39.ErrChecksumIncorrect) {
+			return nil, fmt.Errorf("mnemonic checksum does not match, check every word")
+		}
+		return nil, fmt.Errorf("invalid mnemonic: %w", err)
+	}
+	for i := 0; i < len(passphrase); i++ {
+		if passphrase[i] >= 0x80 {
+			return nil, fmt.Errorf("only ASCII mnemonic passphrases are supported")
+		}
+	}
+	return bip39.NewSeed(normalized, passphrase), nil
+}
+
+// DeriveHDKey derives the private key at a BIP-32 path like m/44'/60'/0'/0/0 from a seed
+func DeriveHDKey(seed []byte, path string) (*ecdsa.PrivateKey, error) {
+	keys, err := DeriveHDKeys(seed, path, 1)
+	if err != nil {
+		return nil, err
+	}
+	return keys[0], nil
+}
+
+// DeriveHDKeys derives count consecutive keys, starting at path and counting up its last index,
+// e.g. m/44'/60'/0'/0/0, m/44'/60'/0'/0/1 and so on
+func DeriveHDKeys(seed []byte, path string, count int) ([]*ecdsa.PrivateKey, error) {
+	derivationPath, err := accounts.ParseDerivationPath(path)
+	if err != nil {
+		return nil, fmt.Errorf("invalid HD path %q: %w", path, err)
+	}
+	if count < 1 {
+		return nil, fmt.Errorf("account count must be at least 1, got %d", count)
+	}
+
+	// Every account shares the parent of the last path element, so derive it once
+	parent, err := bip32.NewMasterKey(seed)
+	if err != nil {
+		return nil, fmt.Errorf("seed gives an invalid master key: %w", err)
+	}
+	last := len(derivationPath) - 1
+	for _, index := range derivationPath[:last] {
+		parent, err = parent.NewChildKey(index)
+		if err != nil {
+			return nil, fmt.Errorf("invalid child key at index %d: %w", index, err)
+		}
+	}
+
+	keys := make([]*ecdsa.PrivateKey, count)
+	for i := range keys {
+		accountPath := make(accounts.DerivationPath, len(derivationPath))
+		copy(accountPath, derivationPath)
+		accountPath[last] += uint32(i)
+
+		child, err := parent.NewChildKey(accountPath[last])
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid child key, try the next index: %w", accountPath, err)
+		}
+		keys[i], err = crypto.ToECDSA(child.Key)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", accountPath, err)
+		}
+	}
+	return keys, nil
+}
@@ -0,0 +1,134 @@
+// Package util provides an append-only journal of claim states for resumable claim runs
+package util
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Claim states recorded in a ClaimJournal
+const (
+	// ClaimPending means a signed claim was about to be sent or is waiting for its receipt
+	ClaimPending = "pending"
+	// ClaimConfirmed means the claim was mined and succeeded
+	ClaimConfirmed = "confirmed"
+	// ClaimReverted means the claim was mined and reverted; resending would revert again
+	ClaimReverted = "reverted"
+	// ClaimFailed means the claim never made it on chain and can be retried
+	ClaimFailed = "failed"
+)
+
+// JournalEntry is one line of a claim journal; the last line for an address is its state
+type JournalEntry struct {
+	Address common.Address `json:"address"`
+	State   string         `json:"state"`
+	TxHash  *common.Hash   `json:"txHash,omitempty"`
+	Detail  string         `json:"detail,omitempty"`
+	Time    time.Time      `json:"time"`
+}
+
+// ClaimJournal appends claim states to a JSONL file, syncing every line, so a crashed run can resume
+// It is safe for concurrent use.
+type ClaimJournal struct {
+	path string
+
+	mu     sync.Mutex
+	file   *os.File
+	latest map[common.Address]JournalEntry
+}
+
+// OpenClaimJournal opens the journal at path. Without resume an existing, non-empty journal is an
+// error, since starting over would resend claims it records. With resume its entries are loaded;
+// a torn last line from a crash is dropped.
+func OpenClaimJournal(path string, resume bool) (*ClaimJournal, error) {
+	journal := &ClaimJournal{path: path, latest: make(map[common.Address]JournalEntry)}
+
+	data, err := os.ReadFile(path)
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		return nil, fmt.Errorf("failed to read claim journal: %w", err)
+	case len(data) > 0 && !resume:
+		return nil, fmt.Errorf("claim journal %s already has entries; resume it or move it away to start over", path)
+	default:
+		if err := journal.load(data); err != nil {
+			return nil, err
+		}
+	}
+
+	journal.file, err = os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open claim journal: %w", err)
+	}
+	return journal, nil
+}
+
+func (j *ClaimJournal) load(data []byte) error {
+	// A crash can leave a torn last line; cut it off so new entries start on a line of their own
+	if end := bytes.LastIndexByte(data, '\n') + 1; end < len(data) {
+		data = data[:end]
+		if err := os.Truncate(j.path, int64(end)); err != nil {
+			return fmt.Errorf("failed to repair claim journal: %w", err)
+		}
+	}
+
+	for i, line := range bytes.Split(data, []byte("\n")) {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		var entry JournalEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			return fmt.Errorf("line %d of %s: %w", i+1, j.path, err)
+		}
+		j.latest[entry.Address] = entry
+	}
+	return nil
+}
+
+// Latest returns the most recent entry for address
+func (j *ClaimJournal) Latest(address common.Address) (JournalEntry, bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	entry, ok := j.latest[address]
+	return entry, ok
+}
+
+// Entries returns the number of addresses with an entry
+func (j *ClaimJournal) Entries() int {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return len(j.latest)
+}
+
+// Record appends an entry and syncs it to disk before returning
+func (j *ClaimJournal) Record(entry JournalEntry) error {
+	if entry.Time.IsZero() {
+		entry.Time = time.Now().UTC()
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode journal entry: %w", err)
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if _, err := j.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write claim journal: %w", err)
+	}
+	if err := j.file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync claim journal: %w", err)
+	}
+	j.latest[entry.Address] = entry
+	return nil
+}
+
+// Close closes the journal file
+func (j *ClaimJournal) Close() error {
+	return j.file.Close()
+}
@@ -0,0 +1,60 @@
+package util
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestClaimJournalResume(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "claims.journal.jsonl")
+	first := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	second := common.HexToAddress("0x2222222222222222222222222222222222222222")
+	txHash := common.HexToHash("0xabc")
+
+	journal, err := OpenClaimJournal(path, false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	journal.Record(JournalEntry{Address: first, State: ClaimPending, TxHash: &txHash})
+	journal.Record(JournalEntry{Address: first, State: ClaimConfirmed, TxHash: &txHash})
+	journal.Record(JournalEntry{Address: second, State: ClaimFailed, Detail: "insufficient funds"})
+	journal.Close()
+
+	// Simulate a crash in the middle of a write
+	file, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o644)
+	file.WriteString(`{"address":"0x2222`)
+	file.Close()
+
+	if _, err := OpenClaimJournal(path, false); err == nil {
+		t.Fatal("Expected an error opening an existing journal without resume")
+	}
+
+	journal, err = OpenClaimJournal(path, true)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if entry, ok := journal.Latest(first); !ok || entry.State != ClaimConfirmed || *entry.TxHash != txHash {
+		t.Errorf("Expected the first address confirmed, got %+v", entry)
+	}
+	if entry, ok := journal.Latest(second); !ok || entry.State != ClaimFailed || entry.Detail != "insufficient funds" {
+		t.Errorf("Expected the second address failed, got %+v", entry)
+	}
+
+	// New entries land on their own line after the torn one is cut off
+	if err := journal.Record(JournalEntry{Address: second, State: ClaimConfirmed}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	journal.Close()
+
+	journal, err = OpenClaimJournal(path, true)
+	if err != nil {
+		t.Fatalf("Unexpected error reopening the journal: %v", err)
+	}
+	defer journal.Close()
+	if entry, _ := journal.Latest(second); entry.State != ClaimConfirmed || journal.Entries() != 2 {
+		t.Errorf("Expected the second address confirmed, got %+v", entry)
+	}
+}
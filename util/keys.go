@@ -0,0 +1,148 @@
+// Package util provides sets of signers kept apart from allocation lists
+package util
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// SignerSet holds the Signer for each address a key source can sign for
+// Allocation lists only hold addresses and amounts, so they can be shared without scrubbing.
+type SignerSet struct {
+	name    string
+	signers map[common.Address]Signer
+}
+
+// NewSignerSet collects signers under a name used in errors; a later signer for an address wins
+func NewSignerSet(name string, signers ...Signer) *SignerSet {
+	set := &SignerSet{name: name, signers: make(map[common.Address]Signer, len(signers))}
+	for _, signer := range signers {
+		set.signers[signer.Address()] = signer
+	}
+	return set
+}
+
+// Signer returns the signer for address. Keystore signers are unlocked here, so a wrong passphrase
+// shows up before anything is signed.
+func (s *SignerSet) Signer(address common.Address) (Signer, error) {
+	signer, ok := s.signers[address]
+	if !ok {
+		return nil, fmt.Errorf("no key for %s in %s", address.Hex(), s.name)
+	}
+	if keystore, ok := signer.(*keystoreSigner); ok {
+		if _, err := keystore.unlock(); err != nil {
+			return nil, err
+		}
+	}
+	return signer, nil
+}
+
+// Len returns the number of addresses in the set
+func (s *SignerSet) Len() int {
+	return len(s.signers)
+}
+
+// NewEnvSigners reads one or more hex private keys, separated by commas or whitespace, from an
+// environment variable. Each key signs for the address it derives.
+func NewEnvSigners(name string) (*SignerSet, error) {
+	value := os.Getenv(name)
+	if strings.TrimSpace(value) == "" {
+		return nil, fmt.Errorf("environment variable %s is not set", name)
+	}
+
+	set := NewSignerSet("$" + name)
+	fields := strings.FieldsFunc(value, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t' || r == '\n' || r == '\r'
+	})
+	for i, field := range fields {
+		signer, err := NewRawKeySigner(field)
+		if err != nil {
+			return nil, fmt.Errorf("key %d of %s: %w", i+1, name, err)
+		}
+		set.signers[signer.Address()] = signer
+	}
+	return set, nil
+}
+
+// NewKeyFileSigners reads an address,private_key CSV, with or without a header row
+// Every key must derive the address it is listed for.
+func NewKeyFileSigners(path string) (*SignerSet, error) {
+	records, _, err := ReadCSV(path, CSVOptions{Positional: []string{ColumnAddress, ColumnPrivateKey}})
+	if err != nil {
+		return nil, err
+	}
+
+	set := NewSignerSet(path)
+	for _, record := range records {
+		signer, err := NewRawKeySigner(record.PrivateKey)
+		if err != nil {
+			return nil, fmt.Errorf("line %d of %s: %w", record.Line, path, err)
+		}
+		if signer.Address() != record.Address {
+			return nil, fmt.Errorf("line %d of %s: key is for %s, not %s", record.Line, path, signer.Address().Hex(), record.Address.Hex())
+		}
+		set.signers[record.Address] = signer
+	}
+	return set, nil
+}
+
+// NewKeystoreDirSigners indexes the V3 keystore files in dir by their address field
+// Files are decrypted with passphrase the first time their address is looked up.
+func NewKeystoreDirSigners(dir, passphrase string) (*SignerSet, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read keystore directory: %w", err)
+	}
+
+	set := NewSignerSet(dir)
+	paths := make(map[common.Address]string)
+	for _, entry := range entries {
+		// Skip editor backups and hidden files, like geth does
+		name := entry.Name()
+		if entry.IsDir() || strings.HasPrefix(name, ".") || strings.HasSuffix(name, "~") {
+			continue
+		}
+		path := filepath.Join(dir, name)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read keystore file: %w", err)
+		}
+		address, err := KeystoreAddress(data)
+		if err != nil {
+			continue
+		}
+		if other, exists := paths[address]; exists {
+			return nil, fmt.Errorf("keystore files %s and %s are both for %s", other, path, address.Hex())
+		}
+		paths[address] = path
+		set.signers[address] = &keystoreSigner{path: path, passphrase: passphrase, address: address}
+	}
+	if set.Len() == 0 {
+		return nil, fmt.Errorf("no keystore files in %s", dir)
+	}
+	return set, nil
+}
+
+// NewMnemonicSigners derives count consecutive accounts from a BIP-39 mnemonic, starting at path
+// and counting up its last index. Each key signs for the address it derives.
+func NewMnemonicSigners(mnemonic, passphrase, path string, count int) (*SignerSet, error) {
+	seed, err := MnemonicToSeed(mnemonic, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := DeriveHDKeys(seed, path, count)
+	if err != nil {
+		return nil, err
+	}
+
+	set := NewSignerSet(fmt.Sprintf("the first %d accounts from %s", count, path))
+	for _, key := range keys {
+		set.signers[crypto.PubkeyToAddress(key.PublicKey)] = NewKeySigner(key)
+	}
+	return set, nil
+}
@@ -0,0 +1,144 @@
+package util
+
+import (
+	"crypto/ecdsa"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/google/uuid"
+)
+
+const testKeyHex = "4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318"
+
+// encryptTestKeystore writes a V3 keystore with cheap scrypt parameters
+func encryptTestKeystore(t *testing.T, key *ecdsa.PrivateKey, passphrase string) []byte {
+	t.Helper()
+	data, err := keystore.EncryptKey(&keystore.Key{
+		Id:         uuid.New(),
+		Address:    crypto.PubkeyToAddress(key.PublicKey),
+		PrivateKey: key,
+	}, passphrase, keystore.LightScryptN, keystore.LightScryptP)
+	if err != nil {
+		t.Fatalf("Failed to encrypt keystore: %v", err)
+	}
+	return data
+}
+
+func TestDecryptKeystore(t *testing.T) {
+	key, _ := crypto.HexToECDSA(testKeyHex)
+	data := encryptTestKeystore(t, key, "secret")
+
+	decrypted, err := DecryptKeystore(data, "secret")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !decrypted.Equal(key) {
+		t.Error("Decrypted key does not match")
+	}
+
+	if _, err := DecryptKeystore(data, "wrong"); err == nil || !strings.Contains(err.Error(), "passphrase") {
+		t.Errorf("Expected a wrong passphrase error, got %v", err)
+	}
+}
+
+func TestDecryptKeystoreSpecVector(t *testing.T) {
+	// The pbkdf2 test vector of the Web3 Secret Storage Definition
+	data := []byte(`{"crypto":{"cipher":"aes-128-ctr","cipherparams":{"iv":"6087dab2f9fdbbfaddc31a909735c1e6"},` +
+		`"ciphertext":"5318b4d5bcd28de64ee5559e671353e16f075ecae9f99c7a79a38af5f869aa46","kdf":"pbkdf2",` +
+		`"kdfparams":{"c":262144,"dklen":32,"prf":"hmac-sha256","salt":"ae3cd4e7013836a3df6bd7241b12db061dbe2c6785853cce422d148a624ce0bd"},` +
+		`"mac":"517ead924a9d0dc3124507e3393d175ce3ff7c1e96529c6c555ce9e51205e9b2"},"id":"3198bc9c-6672-5ab3-d995-4942343ae5b6","version":3}`)
+
+	key, err := DecryptKeystore(data, "testpassword")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := hex.EncodeToString(crypto.FromECDSA(key)); got != "7a28b5ba57c53603b0b07b56bba752f7784bf506fa95edc395f5cf6c7514fe9d" {
+		t.Errorf("Unexpected key %s", got)
+	}
+}
+
+func TestKeystoreDirSigners(t *testing.T) {
+	key, _ := crypto.HexToECDSA(testKeyHex)
+	address := crypto.PubkeyToAddress(key.PublicKey)
+
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "UTC--2024-01-01T00-00-00Z--key"), encryptTestKeystore(t, key, "secret"), 0o600)
+	os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("not a keystore"), 0o600)
+
+	set, err := NewKeystoreDirSigners(dir, "secret")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	found, err := set.Signer(address)
+	if err != nil || found.Address() != address {
+		t.Fatalf("Expected the signer for %s, got %v", address.Hex(), err)
+	}
+	if _, err := set.Signer(crypto.PubkeyToAddress(mustGenerateKey(t).PublicKey)); err == nil {
+		t.Error("Expected an error for an address without a keystore file")
+	}
+
+	// Files are only decrypted when looked up, so a wrong passphrase surfaces there
+	set, err = NewKeystoreDirSigners(dir, "wrong")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := set.Signer(address); err == nil || !strings.Contains(err.Error(), "passphrase") {
+		t.Errorf("Expected a wrong passphrase error, got %v", err)
+	}
+}
+
+func TestKeyFileSigners(t *testing.T) {
+	key, _ := crypto.HexToECDSA(testKeyHex)
+	address := crypto.PubkeyToAddress(key.PublicKey)
+
+	path := writeTestCSV(t, "address,private_key\n"+address.Hex()+",0x"+testKeyHex+"\n")
+	set, err := NewKeyFileSigners(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if found, err := set.Signer(address); err != nil || found.Address() != address {
+		t.Errorf("Expected the signer for %s, got %v", address.Hex(), err)
+	}
+
+	// A key listed under another address is rejected
+	other := crypto.PubkeyToAddress(mustGenerateKey(t).PublicKey)
+	path = writeTestCSV(t, other.Hex()+","+testKeyHex+"\n")
+	if _, err := NewKeyFileSigners(path); err == nil || strings.Contains(err.Error(), testKeyHex) {
+		t.Errorf("Expected an address mismatch error without the key, got %v", err)
+	}
+}
+
+func TestEnvSigners(t *testing.T) {
+	first := mustGenerateKey(t)
+	second := mustGenerateKey(t)
+	t.Setenv("TEST_CLAIM_KEYS", hex.EncodeToString(crypto.FromECDSA(first))+",\n0x"+hex.EncodeToString(crypto.FromECDSA(second)))
+
+	set, err := NewEnvSigners("TEST_CLAIM_KEYS")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, key := range []*ecdsa.PrivateKey{first, second} {
+		address := crypto.PubkeyToAddress(key.PublicKey)
+		if found, err := set.Signer(address); err != nil || found.Address() != address {
+			t.Errorf("Expected a signer for each derived address, got %v", err)
+		}
+	}
+
+	if _, err := NewEnvSigners("TEST_CLAIM_KEYS_UNSET"); err == nil {
+		t.Error("Expected an error for an unset variable")
+	}
+}
+
+func mustGenerateKey(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	return key
+}
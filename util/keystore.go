@@ -0,0 +1,51 @@
+// Package util provides decryption of Ethereum V3 keystore files
+package util
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// keystoreFile is the part of the JSON layout written by geth, clef and most wallets read before decrypting
+type keystoreFile struct {
+	Address string `json:"address"`
+}
+
+// KeystoreAddress returns the address a V3 keystore file declares, without decrypting it
+func KeystoreAddress(data []byte) (common.Address, error) {
+	var file keystoreFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return common.Address{}, fmt.Errorf("invalid keystore JSON: %w", err)
+	}
+	if !common.IsHexAddress(file.Address) {
+		return common.Address{}, fmt.Errorf("keystore has no valid address field")
+	}
+	return common.HexToAddress(file.Address), nil
+}
+
+// DecryptKeystore decrypts a keystore file with its passphrase using go-ethereum's keystore package
+// A key that does not belong to the file's declared address is an error.
+func DecryptKeystore(data []byte, passphrase string) (*ecdsa.PrivateKey, error) {
+	var file keystoreFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("invalid keystore JSON: %w", err)
+	}
+
+	key, err := keystore.DecryptKey(data, passphrase)
+	if errors.Is(err, keystore.ErrDecrypt) {
+		return nil, fmt.Errorf("could not decrypt keystore: wrong passphrase")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not decrypt keystore: %w", err)
+	}
+
+	if file.Address != "" && (!common.IsHexAddress(file.Address) || common.HexToAddress(file.Address) != key.Address) {
+		return nil, fmt.Errorf("keystore key belongs to %s, not the declared address %s", key.Address.Hex(), file.Address)
+	}
+	return key.PrivateKey, nil
+}
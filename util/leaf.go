@@ -0,0 +1,145 @@
+// Package util provides leaf encoding utilities for claim entries
+package util
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"merkle-generator/merkle"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Field names that LeafConfig.Fields can use besides extra CSV columns
+const (
+	FieldIndex   = "index"
+	FieldAddress = "address"
+	FieldAmount  = "amount"
+)
+
+// LeafConfig describes how a claim entry is turned into a Merkle leaf
+type LeafConfig struct {
+	// Types is the Solidity type list, e.g. "uint256,address,uint256"
+	Types string `yaml:"types" json:"types"`
+	// Fields names the source of each type: index, address, amount or an extra CSV column
+	Fields string `yaml:"fields" json:"fields"`
+	// Encoding is packed (abi.encodePacked) or standard (abi.encode)
+	Encoding string `yaml:"encoding" json:"encoding"`
+	// DoubleHash hashes the encoded leaf twice, like OpenZeppelin's StandardMerkleTree
+	DoubleHash bool `yaml:"double_hash" json:"doubleHash"`
+}
+
+// DefaultLeafConfig returns the TokenClaimer.sol leaf: keccak256(abi.encodePacked(address, amount))
+func DefaultLeafConfig() LeafConfig {
+	return LeafConfig{
+		Types:    "address,uint256",
+		Fields:   "address,amount",
+		Encoding: merkle.PackedEncoding.String(),
+	}
+}
+
+// LeafEncoder hashes claim entries according to a LeafConfig
+type LeafEncoder struct {
+	encoder *merkle.LeafEncoder
+	fields  []string
+}
+
+// NewLeafEncoder creates a leaf encoder, filling unset parts of the config with the defaults
+func NewLeafEncoder(config LeafConfig) (*LeafEncoder, error) {
+	defaults := DefaultLeafConfig()
+	if config.Types == "" {
+		if config.Fields != "" {
+			return nil, fmt.Errorf("leaf fields given without leaf types")
+		}
+		config.Types = defaults.Types
+		config.Fields = defaults.Fields
+	}
+	if config.Encoding == "" {
+		config.Encoding = defaults.Encoding
+	}
+
+	types := merkle.ParseTypeList(config.Types)
+	fields := merkle.ParseTypeList(config.Fields)
+	if len(fields) != len(types) {
+		return nil, fmt.Errorf("leaf has %d types but %d fields", len(types), len(fields))
+	}
+
+	encoding, err := merkle.LeafEncodingByName(config.Encoding)
+	if err != nil {
+		return nil, err
+	}
+
+	encoder, err := merkle.NewLeafEncoder(types, encoding, config.DoubleHash)
+	if err != nil {
+		return nil, fmt.Errorf("invalid leaf types: %w", err)
+	}
+
+	return &LeafEncoder{
+		encoder: encoder,
+		fields:  fields,
+	}, nil
+}
+
+// DefaultLeafEncoder returns the encoder for DefaultLeafConfig
+func DefaultLeafEncoder() *LeafEncoder {
+	encoder, err := NewLeafEncoder(DefaultLeafConfig())
+	if err != nil {
+		panic(err) // the default config is always valid
+	}
+	return encoder
+}
+
+// Hash returns the leaf for the entry at the given position
+func (le *LeafEncoder) Hash(index int, testCase TestCase) (common.Hash, error) {
+	values := make([]interface{}, len(le.fields))
+	for i, field := range le.fields {
+		switch strings.ToLower(field) {
+		case FieldIndex:
+			values[i] = big.NewInt(int64(index))
+		case FieldAddress:
+			values[i] = testCase.Address
+		case FieldAmount:
+			values[i] = testCase.Amount
+		default:
+			value, ok := testCase.Extra[field]
+			if !ok {
+				return common.Hash{}, fmt.Errorf("entry %d has no %q column", index, field)
+			}
+			values[i] = value
+		}
+	}
+
+	leaf, err := le.encoder.Hash(values...)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("failed to encode leaf for entry %d: %w", index, err)
+	}
+	return leaf, nil
+}
+
+// Config returns the leaf configuration with defaults filled in
+func (le *LeafEncoder) Config() LeafConfig {
+	return LeafConfig{
+		Types:      strings.Join(le.encoder.Types(), ","),
+		Fields:     strings.Join(le.fields, ","),
+		Encoding:   le.encoder.Encoding().String(),
+		DoubleHash: le.encoder.DoubleHash(),
+	}
+}
+
+// Fields returns the source of each leaf value
+func (le *LeafEncoder) Fields() []string {
+	fields := make([]string, len(le.fields))
+	copy(fields, le.fields)
+	return fields
+}
+
+// Encoder returns the underlying merkle leaf encoder
+func (le *LeafEncoder) Encoder() *merkle.LeafEncoder {
+	return le.encoder
+}
+
+// String describes the leaf, e.g. keccak256(abi.encodePacked(address,uint256))
+func (le *LeafEncoder) String() string {
+	return le.encoder.String()
+}
@@ -0,0 +1,72 @@
+package util
+
+import (
+	"math/big"
+	"testing"
+
+	"merkle-generator/merkle"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestDefaultLeafEncoderMatchesHashAddressAmount(t *testing.T) {
+	testCase := TestCase{
+		Address: common.HexToAddress("0x742d35Cc6634C0532925a3b8D4C9db96C4b4d8b6"),
+		Amount:  big.NewInt(1000000000000000000),
+	}
+
+	leaf, err := DefaultLeafEncoder().Hash(0, testCase)
+	if err != nil {
+		t.Fatalf("Failed to hash leaf: %v", err)
+	}
+	if leaf != merkle.HashAddressAmount(testCase.Address, testCase.Amount) {
+		t.Error("Default leaf should match HashAddressAmount")
+	}
+}
+
+func TestLeafEncoderFields(t *testing.T) {
+	encoder, err := NewLeafEncoder(LeafConfig{
+		Types:  "uint256,address,uint256,uint256",
+		Fields: "index,address,tokenId,amount",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create encoder: %v", err)
+	}
+
+	testCase := TestCase{
+		Address: common.HexToAddress("0x1111111111111111111111111111111111111111"),
+		Amount:  big.NewInt(25),
+		Extra:   map[string]string{"tokenId": "42"},
+	}
+	leaf, err := encoder.Hash(3, testCase)
+	if err != nil {
+		t.Fatalf("Failed to hash leaf: %v", err)
+	}
+
+	expected, _ := encoder.Encoder().Hash(big.NewInt(3), testCase.Address, big.NewInt(42), testCase.Amount)
+	if leaf != expected {
+		t.Errorf("Leaf mismatch:\nExpected: %s\nActual:   %s", expected.Hex(), leaf.Hex())
+	}
+
+	testCase.Extra = nil
+	if _, err := encoder.Hash(3, testCase); err == nil {
+		t.Error("Expected error for missing extra column")
+	}
+}
+
+func TestNewLeafEncoderValidation(t *testing.T) {
+	configs := map[string]LeafConfig{
+		"Field count mismatch": {Types: "address,uint256", Fields: "address"},
+		"Fields without types": {Fields: "address,amount"},
+		"Unknown encoding":     {Types: "address", Fields: "address", Encoding: "rlp"},
+		"Unknown type":         {Types: "address,uint7", Fields: "address,amount"},
+	}
+
+	for name, config := range configs {
+		t.Run(name, func(t *testing.T) {
+			if _, err := NewLeafEncoder(config); err == nil {
+				t.Errorf("Expected error for %s", name)
+			}
+		})
+	}
+}
@@ -0,0 +1,138 @@
+// Package util provides Prometheus-style metrics for the proof server
+package util
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+// durationBuckets are the upper bounds, in seconds, of the request duration histogram
+var durationBuckets = []float64{0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1}
+
+// requestKey labels a request counter
+type requestKey struct {
+	handler string
+	code    int
+}
+
+// durationHistogram is a cumulative histogram in the Prometheus sense
+type durationHistogram struct {
+	buckets []uint64
+	count   uint64
+	sum     float64
+}
+
+// serverMetrics collects request and reload statistics for ProofServer
+// They are written in the Prometheus text exposition format, so no client library is needed.
+type serverMetrics struct {
+	mu             sync.Mutex
+	requests       map[requestKey]uint64
+	durations      map[string]*durationHistogram
+	reloadSuccess  uint64
+	reloadFailure  uint64
+	lastReloadTime time.Time
+}
+
+func newServerMetrics() *serverMetrics {
+	return &serverMetrics{
+		requests:  make(map[requestKey]uint64),
+		durations: make(map[string]*durationHistogram),
+	}
+}
+
+// observeRequest records one handled request
+func (m *serverMetrics) observeRequest(handler string, code int, duration time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.requests[requestKey{handler: handler, code: code}]++
+
+	histogram, ok := m.durations[handler]
+	if !ok {
+		histogram = &durationHistogram{buckets: make([]uint64, len(durationBuckets))}
+		m.durations[handler] = histogram
+	}
+	seconds := duration.Seconds()
+	for i, bound := range durationBuckets {
+		if seconds <= bound {
+			histogram.buckets[i]++
+		}
+	}
+	histogram.count++
+	histogram.sum += seconds
+}
+
+// observeReload records a reload attempt
+func (m *serverMetrics) observeReload(err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err != nil {
+		m.reloadFailure++
+		return
+	}
+	m.reloadSuccess++
+	m.lastReloadTime = time.Now()
+}
+
+// write prints all metrics, including gauges for the currently served distribution
+func (m *serverMetrics) write(w io.Writer, snapshot *proofSnapshot) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	fmt.Fprintln(w, "# HELP merkle_server_requests_total HTTP requests handled, by handler and status code.")
+	fmt.Fprintln(w, "# TYPE merkle_server_requests_total counter")
+	keys := make([]requestKey, 0, len(m.requests))
+	for key := range m.requests {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].handler != keys[j].handler {
+			return keys[i].handler < keys[j].handler
+		}
+		return keys[i].code < keys[j].code
+	})
+	for _, key := range keys {
+		fmt.Fprintf(w, "merkle_server_requests_total{handler=%q,code=\"%d\"} %d\n", key.handler, key.code, m.requests[key])
+	}
+
+	fmt.Fprintln(w, "# HELP merkle_server_request_duration_seconds Time spent handling HTTP requests.")
+	fmt.Fprintln(w, "# TYPE merkle_server_request_duration_seconds histogram")
+	handlers := make([]string, 0, len(m.durations))
+	for handler := range m.durations {
+		handlers = append(handlers, handler)
+	}
+	sort.Strings(handlers)
+	for _, handler := range handlers {
+		histogram := m.durations[handler]
+		for i, bound := range durationBuckets {
+			fmt.Fprintf(w, "merkle_server_request_duration_seconds_bucket{handler=%q,le=\"%g\"} %d\n", handler, bound, histogram.buckets[i])
+		}
+		fmt.Fprintf(w, "merkle_server_request_duration_seconds_bucket{handler=%q,le=\"+Inf\"} %d\n", handler, histogram.count)
+		fmt.Fprintf(w, "merkle_server_request_duration_seconds_sum{handler=%q} %g\n", handler, histogram.sum)
+		fmt.Fprintf(w, "merkle_server_request_duration_seconds_count{handler=%q} %d\n", handler, histogram.count)
+	}
+
+	fmt.Fprintln(w, "# HELP merkle_server_reloads_total Attempts to load the distribution, by result.")
+	fmt.Fprintln(w, "# TYPE merkle_server_reloads_total counter")
+	fmt.Fprintf(w, "merkle_server_reloads_total{result=\"success\"} %d\n", m.reloadSuccess)
+	fmt.Fprintf(w, "merkle_server_reloads_total{result=\"failure\"} %d\n", m.reloadFailure)
+
+	fmt.Fprintln(w, "# HELP merkle_server_last_reload_timestamp_seconds Unix time of the last successful load.")
+	fmt.Fprintln(w, "# TYPE merkle_server_last_reload_timestamp_seconds gauge")
+	fmt.Fprintf(w, "merkle_server_last_reload_timestamp_seconds %d\n", m.lastReloadTime.Unix())
+
+	if snapshot == nil {
+		return
+	}
+	fmt.Fprintln(w, "# HELP merkle_server_entries Recipients in the served distribution.")
+	fmt.Fprintln(w, "# TYPE merkle_server_entries gauge")
+	fmt.Fprintf(w, "merkle_server_entries %d\n", snapshot.artifact.EntryCount)
+
+	fmt.Fprintln(w, "# HELP merkle_server_distribution_info The served Merkle root.")
+	fmt.Fprintln(w, "# TYPE merkle_server_distribution_info gauge")
+	fmt.Fprintf(w, "merkle_server_distribution_info{root=%q} 1\n", snapshot.artifact.Root.Hex())
+}
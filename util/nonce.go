@@ -0,0 +1,144 @@
+// Package util provides local nonce tracking for senders with many transactions in flight
+package util
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// NonceSource reads an account's next nonce including pending transactions, like ethclient.Client
+type NonceSource interface {
+	PendingNonceAt(ctx context.Context, account common.Address) (uint64, error)
+}
+
+// NonceManager hands out consecutive nonces per sender without waiting for the node to see the
+// previous transaction. Every reserved nonce ends with Sent or Release, and the node is only asked
+// again while none are outstanding, so a resync can never hand out a nonce already in flight.
+// It is safe for concurrent use.
+type NonceManager struct {
+	source NonceSource
+
+	mu      sync.Mutex
+	senders map[common.Address]*senderNonces
+}
+
+// senderNonces is the local nonce state of one sender
+type senderNonces struct {
+	// next is the lowest nonce never handed out
+	next uint64
+	// reserved holds nonces handed out but not yet sent or released
+	reserved map[uint64]struct{}
+	// released holds nonces below next that were handed back; they are reused first to close the gap
+	released map[uint64]struct{}
+	// stale asks for the node's pending nonce once nothing is reserved
+	stale bool
+}
+
+// NewNonceManager returns a NonceManager that reads starting nonces from source
+func NewNonceManager(source NonceSource) *NonceManager {
+	return &NonceManager{source: source, senders: make(map[common.Address]*senderNonces)}
+}
+
+// Next reserves the next nonce for address, asking the node only the first time, or after a
+// Resync once no nonce is reserved. Released nonces are handed out again before new ones.
+// A reserved nonce must end with Sent or Release.
+func (m *NonceManager) Next(ctx context.Context, address common.Address) (uint64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	sender, ok := m.senders[address]
+	if !ok || (sender.stale && len(sender.reserved) == 0) {
+		pending, err := m.source.PendingNonceAt(ctx, address)
+		if err != nil {
+			return 0, fmt.Errorf("failed to get nonce: %w", err)
+		}
+		sender = &senderNonces{next: pending, reserved: make(map[uint64]struct{}), released: make(map[uint64]struct{})}
+		m.senders[address] = sender
+	}
+
+	nonce := sender.next
+	for released := range sender.released {
+		if released < nonce {
+			nonce = released
+		}
+	}
+	if nonce == sender.next {
+		sender.next++
+	} else {
+		delete(sender.released, nonce)
+	}
+	sender.reserved[nonce] = struct{}{}
+	return nonce, nil
+}
+
+// Sent marks a reserved nonce as used by a transaction the node accepted
+func (m *NonceManager) Sent(address common.Address, nonce uint64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if sender, ok := m.senders[address]; ok {
+		delete(sender.reserved, nonce)
+	}
+}
+
+// Release hands back a reserved nonce that was never sent. It is handed out again before any new
+// nonce, so a later nonce already in flight is not left behind a gap.
+func (m *NonceManager) Release(address common.Address, nonce uint64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	sender, ok := m.senders[address]
+	if !ok {
+		return
+	}
+	if _, reserved := sender.reserved[nonce]; !reserved {
+		return
+	}
+	delete(sender.reserved, nonce)
+	sender.released[nonce] = struct{}{}
+
+	// Released nonces at the top need no gap filling
+	for sender.next > 0 {
+		if _, ok := sender.released[sender.next-1]; !ok {
+			break
+		}
+		sender.next--
+		delete(sender.released, sender.next)
+	}
+}
+
+// Resync marks the local nonces for address as wrong, e.g. after "nonce too low". The node is asked
+// for the pending nonce on the next reservation made while no other nonce is outstanding.
+func (m *NonceManager) Resync(address common.Address) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if sender, ok := m.senders[address]; ok {
+		sender.stale = true
+	}
+}
+
+// IsNonceError reports whether the node rejected a send for its nonce, "nonce too low" or "nonce too
+// high", meaning the local nonces are out of step with the node and need a resync
+func IsNonceError(err error) bool {
+	return IsNonceTooLow(err) || IsNonceTooHigh(err)
+}
+
+// IsAlreadyKnown reports whether a send failed because the node already has this very transaction
+func IsAlreadyKnown(err error) bool {
+	return err != nil && strings.Contains(strings.ToLower(err.Error()), "already known")
+}
+
+// IsNonceTooHigh reports whether a send failed because the nonce is ahead of the sender's account,
+// so the local nonces have a gap the node will not fill
+func IsNonceTooHigh(err error) bool {
+	return err != nil && strings.Contains(strings.ToLower(err.Error()), "nonce too high")
+}
+
+// IsNonceTooLow reports whether a send failed because another transaction was mined with its nonce
+// Unlike "already known", the transaction itself never made it, so it is safe to resend with a new nonce.
+func IsNonceTooLow(err error) bool {
+	return err != nil && strings.Contains(strings.ToLower(err.Error()), "nonce too low")
+}
@@ -0,0 +1,236 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// fakeNonces is a NonceSource with a settable pending nonce that counts its calls
+type fakeNonces struct {
+	mu      sync.Mutex
+	pending uint64
+	calls   int
+}
+
+func (f *fakeNonces) PendingNonceAt(ctx context.Context, account common.Address) (uint64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls++
+	return f.pending, nil
+}
+
+func TestNonceManagerNext(t *testing.T) {
+	source := &fakeNonces{pending: 7}
+	nonces := NewNonceManager(source)
+	address := common.HexToAddress("0x1111111111111111111111111111111111111111")
+
+	for want := uint64(7); want < 10; want++ {
+		if got, err := nonces.Next(context.Background(), address); err != nil || got != want {
+			t.Errorf("Expected nonce %d, got %d (%v)", want, got, err)
+		}
+	}
+	if source.calls != 1 {
+		t.Errorf("Expected one node lookup, got %d", source.calls)
+	}
+
+	// Handing back the latest nonce reuses it
+	nonces.Release(address, 9)
+	if got, _ := nonces.Next(context.Background(), address); got != 9 {
+		t.Errorf("Expected the released nonce 9, got %d", got)
+	}
+
+	// After a resync the node's pending nonce wins, once the reserved nonces are sent
+	for nonce := uint64(7); nonce < 10; nonce++ {
+		nonces.Sent(address, nonce)
+	}
+	source.pending = 20
+	nonces.Resync(address)
+	if got, _ := nonces.Next(context.Background(), address); got != 20 {
+		t.Errorf("Expected nonce 20 after resync, got %d", got)
+	}
+
+	// Releasing an older nonce leaves a gap, which is filled before any new nonce
+	nonces.Next(context.Background(), address)
+	nonces.Sent(address, 21)
+	nonces.Release(address, 20)
+	if got, _ := nonces.Next(context.Background(), address); got != 20 || source.calls != 2 {
+		t.Errorf("Expected the gap at nonce 20 without a lookup, got %d after %d lookups", got, source.calls)
+	}
+	if got, _ := nonces.Next(context.Background(), address); got != 22 {
+		t.Errorf("Expected nonce 22 after the gap, got %d", got)
+	}
+}
+
+func TestNonceManagerResyncWaitsForReservations(t *testing.T) {
+	source := &fakeNonces{pending: 3}
+	nonces := NewNonceManager(source)
+	address := common.HexToAddress("0x1111111111111111111111111111111111111111")
+
+	nonces.Next(context.Background(), address)
+	outstanding, _ := nonces.Next(context.Background(), address)
+	nonces.Sent(address, 3)
+
+	// Nonce 4 is still reserved, so the node's view can't be trusted yet
+	source.pending = 4
+	nonces.Resync(address)
+	if got, _ := nonces.Next(context.Background(), address); got != 5 || source.calls != 1 {
+		t.Errorf("Expected local nonce 5 while 4 is reserved, got %d after %d lookups", got, source.calls)
+	}
+
+	nonces.Sent(address, outstanding)
+	nonces.Sent(address, 5)
+	source.pending = 9
+	if got, _ := nonces.Next(context.Background(), address); got != 9 || source.calls != 2 {
+		t.Errorf("Expected a resync to nonce 9 once nothing is reserved, got %d after %d lookups", got, source.calls)
+	}
+}
+
+func TestNonceManagerConcurrent(t *testing.T) {
+	nonces := NewNonceManager(&fakeNonces{})
+	address := common.HexToAddress("0x1111111111111111111111111111111111111111")
+
+	var mu sync.Mutex
+	seen := make(map[uint64]bool)
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			nonce, _ := nonces.Next(context.Background(), address)
+			mu.Lock()
+			seen[nonce] = true
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	for nonce := uint64(0); nonce < 50; nonce++ {
+		if !seen[nonce] {
+			t.Errorf("Nonce %d was never handed out", nonce)
+		}
+	}
+}
+
+// fakeNode serves eth_getTransactionCount and eth_sendRawTransaction for one sender. The pending
+// nonce is the lowest one not yet accepted, like a node's pool with a gap. Every third nonce fails
+// its first send with an error unrelated to nonces.
+type fakeNode struct {
+	mu         sync.Mutex
+	accepted   map[uint64]bool
+	attempts   map[uint64]int
+	duplicates int
+}
+
+func (n *fakeNode) ChainId() hexutil.Big {
+	return hexutil.Big(*big.NewInt(1337))
+}
+
+func (n *fakeNode) GetTransactionCount(address common.Address, block string) hexutil.Uint64 {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	var pending uint64
+	for n.accepted[pending] {
+		pending++
+	}
+	return hexutil.Uint64(pending)
+}
+
+func (n *fakeNode) SendRawTransaction(data hexutil.Bytes) (common.Hash, error) {
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(data); err != nil {
+		return common.Hash{}, err
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	nonce := tx.Nonce()
+	n.attempts[nonce]++
+	switch {
+	case n.accepted[nonce]:
+		n.duplicates++
+		return common.Hash{}, errors.New("replacement transaction underpriced")
+	case nonce%3 == 1 && n.attempts[nonce] == 1:
+		return common.Hash{}, errors.New("insufficient funds for gas * price + value")
+	}
+	n.accepted[nonce] = true
+	return tx.Hash(), nil
+}
+
+func TestNonceManagerConcurrentSends(t *testing.T) {
+	node := &fakeNode{accepted: make(map[uint64]bool), attempts: make(map[uint64]int)}
+	server := rpc.NewServer()
+	if err := server.RegisterName("eth", node); err != nil {
+		t.Fatalf("Failed to register fake node: %v", err)
+	}
+	defer server.Stop()
+
+	client := &EthClient{Client: ethclient.NewClient(rpc.DialInProc(server)), ChainID: big.NewInt(1337)}
+	client.Nonces = NewNonceManager(client)
+	signer := NewKeySigner(mustGenerateKey(t))
+
+	const workers, sends = 8, 25
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for sent := 0; sent < sends; {
+				account, err := client.GetAccountInfoForSigner(signer)
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+					return
+				}
+				// Every other attempt gives its nonce back unsent, like a failed gas estimate
+				if account.Nonce%2 == 0 && sent%2 == 1 {
+					client.ReleaseNonce(account.Address, account.Nonce)
+					sent++
+					continue
+				}
+				tx := types.NewTransaction(account.Nonce, common.Address{}, big.NewInt(0), 21000, big.NewInt(1), nil)
+				if _, err := client.SignAndSendTransactionWithSigner(tx, signer); err == nil {
+					sent++
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	node.mu.Lock()
+	defer node.mu.Unlock()
+	if node.duplicates != 0 {
+		t.Errorf("Expected no nonce to be sent twice after it was accepted, got %d", node.duplicates)
+	}
+	// Released and failed nonces are reused, so the accepted nonces have no gaps
+	for nonce := uint64(0); nonce < uint64(len(node.accepted)); nonce++ {
+		if !node.accepted[nonce] {
+			t.Errorf("Nonce %d was skipped", nonce)
+		}
+	}
+	next, _ := client.Nonces.Next(context.Background(), signer.Address())
+	if next != uint64(len(node.accepted)) {
+		t.Errorf("Expected the next nonce %d, got %d", len(node.accepted), next)
+	}
+}
+
+func TestIsNonceError(t *testing.T) {
+	if !IsNonceTooLow(errors.New("failed to send transaction: nonce too low")) || !IsNonceError(errors.New("nonce too low")) {
+		t.Error("Expected nonce too low to be a nonce error")
+	}
+	if !IsNonceTooHigh(errors.New("nonce too high")) || !IsNonceError(errors.New("nonce too high")) {
+		t.Error("Expected nonce too high to be a nonce error")
+	}
+	if IsNonceTooLow(errors.New("already known")) || IsNonceError(errors.New("already known")) {
+		t.Error("Expected already known not to be a nonce error, since the nonce is fine")
+	}
+	if IsNonceError(errors.New("insufficient funds")) || IsNonceError(nil) {
+		t.Error("Expected other errors not to be nonce errors")
+	}
+}
@@ -0,0 +1,34 @@
+package util
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter(t *testing.T) {
+	limiter := NewRateLimiter(100)
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		if err := limiter.Wait(context.Background()); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	// The first call passes at once, the next four are 10ms apart
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+		t.Errorf("Expected at least 40ms for 5 calls at 100/s, took %s", elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	slow := NewRateLimiter(0.1)
+	slow.Wait(context.Background())
+	if err := slow.Wait(ctx); err == nil {
+		t.Error("Expected a canceled wait to fail")
+	}
+
+	unlimited := NewRateLimiter(0)
+	if unlimited != nil || unlimited.Wait(ctx) != nil {
+		t.Error("Expected no limiter for a zero rate")
+	}
+}
@@ -0,0 +1,150 @@
+// Package util provides receipt polling and revert reason decoding
+package util
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Receipt polling defaults
+const (
+	DefaultConfirmations  = 1
+	DefaultReceiptTimeout = 5 * time.Minute
+	DefaultPollInterval   = 2 * time.Second
+)
+
+// ErrReceiptTimeout is returned when a transaction is not mined and confirmed in time
+var ErrReceiptTimeout = errors.New("timed out waiting for transaction receipt")
+
+// ReceiptOptions controls WaitForReceipt; zero fields take the defaults
+type ReceiptOptions struct {
+	// Confirmations counts the inclusion block, so 1 returns as soon as the transaction is mined
+	Confirmations uint64
+	Timeout       time.Duration
+	PollInterval  time.Duration
+}
+
+// WaitForReceipt polls until the transaction has the requested confirmations and returns its receipt
+// The receipt is fetched again on every poll, so a transaction dropped by a reorg is waited for again.
+// Canceling ctx returns an error wrapping context.Canceled rather than ErrReceiptTimeout.
+func (ec *EthClient) WaitForReceipt(ctx context.Context, txHash common.Hash, opts ReceiptOptions) (*types.Receipt, error) {
+	if opts.Confirmations == 0 {
+		opts.Confirmations = DefaultConfirmations
+	}
+	if opts.Timeout == 0 {
+		opts.Timeout = DefaultReceiptTimeout
+	}
+	if opts.PollInterval == 0 {
+		opts.PollInterval = DefaultPollInterval
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+	ticker := time.NewTicker(opts.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		receipt, err := ec.TransactionReceipt(ctx, txHash)
+		switch {
+		case err == nil:
+			head, err := ec.BlockNumber(ctx)
+			if err == nil && head+1 >= receipt.BlockNumber.Uint64()+opts.Confirmations {
+				return receipt, nil
+			}
+		case !errors.Is(err, ethereum.NotFound) && ctx.Err() == nil:
+			return nil, fmt.Errorf("failed to get receipt for %s: %w", txHash.Hex(), err)
+		}
+
+		select {
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.Canceled) {
+				return nil, fmt.Errorf("stopped waiting for %s: %w", txHash.Hex(), ctx.Err())
+			}
+			return nil, fmt.Errorf("%w for %s after %s", ErrReceiptTimeout, txHash.Hex(), opts.Timeout)
+		case <-ticker.C:
+		}
+	}
+}
+
+// RevertData replays a mined transaction as a call in its block and returns the revert data
+// It returns nil data when the replay does not revert, e.g. when the transaction ran out of gas.
+func (ec *EthClient) RevertData(ctx context.Context, tx *types.Transaction, blockNumber *big.Int) ([]byte, error) {
+	from, err := types.Sender(types.LatestSignerForChainID(ec.ChainID), tx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to recover sender: %w", err)
+	}
+
+	_, err = ec.CallContract(ctx, ethereum.CallMsg{
+		From:  from,
+		To:    tx.To(),
+		Gas:   tx.Gas(),
+		Value: tx.Value(),
+		Data:  tx.Data(),
+	}, blockNumber)
+	if err == nil {
+		return nil, nil
+	}
+
+	var dataErr rpc.DataError
+	if errors.As(err, &dataErr) {
+		if encoded, ok := dataErr.ErrorData().(string); ok {
+			if data, decodeErr := hexutil.Decode(encoded); decodeErr == nil {
+				return data, nil
+			}
+		}
+	}
+	if strings.Contains(err.Error(), "revert") {
+		// The node reverted without returning data
+		return []byte{}, nil
+	}
+	return nil, fmt.Errorf("failed to replay transaction: %w", err)
+}
+
+// panicSelector is the selector of Solidity's Panic(uint256)
+var panicSelector = []byte{0x4e, 0x48, 0x7b, 0x71}
+
+// DecodeRevertReason turns revert data into a readable reason: Error(string), Panic(uint256) or a
+// custom error declared in contractABI, like AlreadyClaimed(address)
+func DecodeRevertReason(contractABI abi.ABI, data []byte) string {
+	if len(data) < 4 {
+		return "execution reverted without a reason"
+	}
+	if reason, err := abi.UnpackRevert(data); err == nil {
+		return reason
+	}
+	if bytes.Equal(data[:4], panicSelector) && len(data) >= 36 {
+		return fmt.Sprintf("panic 0x%x", new(big.Int).SetBytes(data[4:36]))
+	}
+
+	for _, customErr := range contractABI.Errors {
+		if !bytes.Equal(customErr.ID[:4], data[:4]) {
+			continue
+		}
+		values, err := customErr.Inputs.Unpack(data[4:])
+		if err != nil {
+			return customErr.Name + "(<undecodable arguments>)"
+		}
+		args := make([]string, len(values))
+		for i, value := range values {
+			if address, ok := value.(common.Address); ok {
+				args[i] = address.Hex()
+			} else {
+				args[i] = fmt.Sprint(value)
+			}
+		}
+		return customErr.Name + "(" + strings.Join(args, ", ") + ")"
+	}
+	return fmt.Sprintf("unknown custom error 0x%x", data[:4])
+}
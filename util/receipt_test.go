@@ -0,0 +1,66 @@
+package util
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestDecodeRevertReason(t *testing.T) {
+	contractABI, err := abi.JSON(strings.NewReader(TokenClaimerABI))
+	if err != nil {
+		t.Fatalf("Failed to parse ABI: %v", err)
+	}
+
+	// Error(string) from require(..., "message")
+	stringType, _ := abi.NewType("string", "", nil)
+	message, err := abi.Arguments{{Type: stringType}}.Pack("Already claimed")
+	if err != nil {
+		t.Fatalf("Failed to pack message: %v", err)
+	}
+	errorData := append(crypto.Keccak256([]byte("Error(string)"))[:4], message...)
+	if got := DecodeRevertReason(contractABI, errorData); got != "Already claimed" {
+		t.Errorf("Expected the require message, got %q", got)
+	}
+
+	// Custom errors from the contract ABI
+	account := common.HexToAddress("0x742d35Cc6634C0532925a3b8D4C9db96C4b4d8b6")
+	alreadyClaimed := contractABI.Errors["AlreadyClaimed"]
+	args, err := alreadyClaimed.Inputs.Pack(account)
+	if err != nil {
+		t.Fatalf("Failed to pack error: %v", err)
+	}
+	if got := DecodeRevertReason(contractABI, append(alreadyClaimed.ID[:4:4], args...)); got != "AlreadyClaimed("+account.Hex()+")" {
+		t.Errorf("Unexpected custom error %q", got)
+	}
+	invalidProof := contractABI.Errors["InvalidProof"]
+	if got := DecodeRevertReason(contractABI, invalidProof.ID[:4]); got != "InvalidProof()" {
+		t.Errorf("Unexpected custom error %q", got)
+	}
+
+	if got := DecodeRevertReason(contractABI, []byte{0xde, 0xad, 0xbe, 0xef}); got != "unknown custom error 0xdeadbeef" {
+		t.Errorf("Unexpected unknown error %q", got)
+	}
+	if got := DecodeRevertReason(contractABI, nil); !strings.Contains(got, "without a reason") {
+		t.Errorf("Unexpected empty revert %q", got)
+	}
+}
+
+func TestReceiptsConfigOptions(t *testing.T) {
+	opts, err := ReceiptsConfig{Confirmations: 3, Timeout: "90s"}.Options()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if opts.Confirmations != 3 || opts.Timeout.Seconds() != 90 || opts.PollInterval != 0 {
+		t.Errorf("Unexpected options %+v", opts)
+	}
+
+	for _, config := range []ReceiptsConfig{{Timeout: "soon"}, {PollInterval: "-1s"}} {
+		if _, err := config.Options(); err == nil {
+			t.Errorf("Expected an error for %+v", config)
+		}
+	}
+}
@@ -0,0 +1,437 @@
+// Package util provides an HTTP proof server for claim frontends
+package util
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"merkle-generator/merkle"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// maxVerifyRequestSize bounds the body of a POST /verify request
+const maxVerifyRequestSize = 1 << 20
+
+// ProofServerConfig configures a ProofServer
+type ProofServerConfig struct {
+	// Path is an allocation CSV, or a distribution artifact written by build when it ends in .json
+	Path string
+	// Leaf and Tree describe how a CSV is turned into a tree; artifacts record their own
+	Leaf LeafConfig
+	Tree TreeConfig
+	// CSV selects the columns of a CSV and whether bad rows are skipped
+	CSV CSVOptions
+	// ReloadInterval is how often Watch checks Path for changes
+	ReloadInterval time.Duration
+	// CORSOrigin is sent as Access-Control-Allow-Origin when set, e.g. "*" or the dApp's origin
+	CORSOrigin string
+	// Logf reports reloads, log.Printf when nil
+	Logf func(format string, args ...interface{})
+}
+
+// ProofServer serves the root and per-recipient proofs of a distribution over HTTP
+// The tree is built once per load; Watch reloads it when the input file changes.
+type ProofServer struct {
+	config  ProofServerConfig
+	metrics *serverMetrics
+
+	mu       sync.RWMutex
+	snapshot *proofSnapshot
+}
+
+// proofSnapshot is one loaded version of the distribution
+type proofSnapshot struct {
+	artifact *DistributionArtifact
+	// byAddress maps an address to its first entry
+	byAddress map[common.Address]int
+	encoder   *LeafEncoder
+	opts      []merkle.Option
+	pairs     merkle.PairStrategy
+	loadedAt  time.Time
+	// modTime and size identify the file version the snapshot was loaded from
+	modTime time.Time
+	size    int64
+}
+
+// RootResponse is returned by GET /root
+type RootResponse struct {
+	Root         common.Hash `json:"root"`
+	Tree         TreeConfig  `json:"tree"`
+	LeafEncoding LeafConfig  `json:"leafEncoding"`
+	TotalAmount  string      `json:"totalAmount"`
+	EntryCount   int         `json:"entryCount"`
+	LoadedAt     time.Time   `json:"loadedAt"`
+}
+
+// ProofResponse is returned by GET /proof/{address}
+type ProofResponse struct {
+	Root common.Hash `json:"root"`
+	// EntryCount is the number of leaves, which positional proofs need along with the index
+	EntryCount int `json:"entryCount"`
+	DistributionEntry
+}
+
+// VerifyRequest is the body of POST /verify
+// Either Leaf or Address and Amount must be set. Root defaults to the served root and Index to
+// the address's position in the distribution.
+type VerifyRequest struct {
+	Root    *common.Hash    `json:"root,omitempty"`
+	Leaf    *common.Hash    `json:"leaf,omitempty"`
+	Address *common.Address `json:"address,omitempty"`
+	Amount  string          `json:"amount,omitempty"`
+	Index   *int            `json:"index,omitempty"`
+	Proof   []common.Hash   `json:"proof"`
+}
+
+// VerifyResponse is returned by POST /verify
+type VerifyResponse struct {
+	Valid bool        `json:"valid"`
+	Root  common.Hash `json:"root"`
+	Leaf  common.Hash `json:"leaf"`
+}
+
+// errorResponse is returned with every non-2xx status
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// NewProofServer loads the distribution and returns a server for it
+func NewProofServer(config ProofServerConfig) (*ProofServer, error) {
+	if config.Logf == nil {
+		config.Logf = log.Printf
+	}
+
+	ps := &ProofServer{
+		config:  config,
+		metrics: newServerMetrics(),
+	}
+	if err := ps.Reload(); err != nil {
+		return nil, err
+	}
+	return ps, nil
+}
+
+// Root returns the currently served Merkle root
+func (ps *ProofServer) Root() common.Hash {
+	return ps.current().artifact.Root
+}
+
+// Reload loads the input file again and starts serving it if it is valid
+// On failure the previous distribution keeps being served.
+func (ps *ProofServer) Reload() error {
+	snapshot, err := loadProofSnapshot(ps.config)
+	ps.metrics.observeReload(err)
+	if err != nil {
+		return err
+	}
+
+	ps.mu.Lock()
+	ps.snapshot = snapshot
+	ps.mu.Unlock()
+	return nil
+}
+
+// Watch polls the input file every ReloadInterval and reloads it when it changes, until ctx is done
+func (ps *ProofServer) Watch(ctx context.Context) {
+	if ps.config.ReloadInterval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(ps.config.ReloadInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		info, err := os.Stat(ps.config.Path)
+		if err != nil {
+			ps.config.Logf("Checking %s for changes failed: %v", ps.config.Path, err)
+			continue
+		}
+		current := ps.current()
+		if info.ModTime().Equal(current.modTime) && info.Size() == current.size {
+			continue
+		}
+
+		if err := ps.Reload(); err != nil {
+			ps.config.Logf("Reloading %s failed, still serving root %s: %v", ps.config.Path, current.artifact.Root.Hex(), err)
+			// Remember the broken version so it is not retried on every tick
+			ps.mu.Lock()
+			if ps.snapshot == current {
+				stale := *current
+				stale.modTime = info.ModTime()
+				stale.size = info.Size()
+				ps.snapshot = &stale
+			}
+			ps.mu.Unlock()
+			continue
+		}
+		ps.config.Logf("Reloaded %s, now serving root %s", ps.config.Path, ps.Root().Hex())
+	}
+}
+
+// Handler returns the HTTP handler serving /root, /proof/{address}, /verify and /metrics
+func (ps *ProofServer) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/root", ps.instrument("root", http.MethodGet, ps.handleRoot))
+	mux.Handle("/proof/", ps.instrument("proof", http.MethodGet, ps.handleProof))
+	mux.Handle("/verify", ps.instrument("verify", http.MethodPost, ps.handleVerify))
+	mux.Handle("/metrics", ps.instrument("metrics", http.MethodGet, ps.handleMetrics))
+	return mux
+}
+
+func (ps *ProofServer) current() *proofSnapshot {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+	return ps.snapshot
+}
+
+func (ps *ProofServer) handleRoot(w http.ResponseWriter, r *http.Request) {
+	snapshot := ps.current()
+	writeJSONResponse(w, http.StatusOK, RootResponse{
+		Root:         snapshot.artifact.Root,
+		Tree:         snapshot.artifact.Tree,
+		LeafEncoding: snapshot.artifact.LeafEncoding,
+		TotalAmount:  snapshot.artifact.TotalAmount,
+		EntryCount:   snapshot.artifact.EntryCount,
+		LoadedAt:     snapshot.loadedAt,
+	})
+}
+
+func (ps *ProofServer) handleProof(w http.ResponseWriter, r *http.Request) {
+	input := strings.TrimPrefix(r.URL.Path, "/proof/")
+	if !common.IsHexAddress(input) {
+		writeErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("invalid address %q", input))
+		return
+	}
+	address := common.HexToAddress(input)
+
+	snapshot := ps.current()
+	index, ok := snapshot.byAddress[address]
+	if !ok {
+		writeErrorResponse(w, http.StatusNotFound, fmt.Sprintf("address %s is not in the distribution", address.Hex()))
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, ProofResponse{
+		Root:              snapshot.artifact.Root,
+		EntryCount:        snapshot.artifact.EntryCount,
+		DistributionEntry: snapshot.artifact.Entries[index],
+	})
+}
+
+func (ps *ProofServer) handleVerify(w http.ResponseWriter, r *http.Request) {
+	var request VerifyRequest
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxVerifyRequestSize))
+	if err := decoder.Decode(&request); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+		return
+	}
+
+	snapshot := ps.current()
+	response, err := snapshot.verify(request)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeJSONResponse(w, http.StatusOK, response)
+}
+
+func (ps *ProofServer) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	ps.metrics.write(w, ps.current())
+}
+
+// instrument restricts a handler to one method, adds CORS headers and records metrics
+func (ps *ProofServer) instrument(name, method string, handler http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		if ps.config.CORSOrigin != "" {
+			recorder.Header().Set("Access-Control-Allow-Origin", ps.config.CORSOrigin)
+			recorder.Header().Set("Access-Control-Allow-Methods", method+", OPTIONS")
+			recorder.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+		}
+
+		switch r.Method {
+		case method:
+			handler(recorder, r)
+		case http.MethodOptions:
+			recorder.WriteHeader(http.StatusNoContent)
+		default:
+			recorder.Header().Set("Allow", method+", OPTIONS")
+			writeErrorResponse(recorder, http.StatusMethodNotAllowed, fmt.Sprintf("method %s not allowed", r.Method))
+		}
+
+		ps.metrics.observeRequest(name, recorder.status, time.Since(start))
+	})
+}
+
+// verify checks a proof against the snapshot's tree configuration
+func (s *proofSnapshot) verify(request VerifyRequest) (VerifyResponse, error) {
+	if request.Proof == nil {
+		return VerifyResponse{}, errors.New("missing proof")
+	}
+
+	root := s.artifact.Root
+	if request.Root != nil {
+		root = *request.Root
+	}
+
+	index := -1
+	if request.Index != nil {
+		index = *request.Index
+	} else if request.Address != nil {
+		if i, ok := s.byAddress[*request.Address]; ok {
+			index = s.artifact.Entries[i].Index
+		}
+	}
+
+	var leaf common.Hash
+	switch {
+	case request.Leaf != nil:
+		leaf = *request.Leaf
+	case request.Address != nil && request.Amount != "":
+		amount, ok := new(big.Int).SetString(request.Amount, 10)
+		if !ok || amount.Sign() < 0 {
+			return VerifyResponse{}, fmt.Errorf("invalid amount %q", request.Amount)
+		}
+		// Leaves that do not use the index encode the same way for any position
+		leafIndex := index
+		if leafIndex < 0 {
+			leafIndex = 0
+		}
+		hash, err := s.encoder.Hash(leafIndex, TestCase{Address: *request.Address, Amount: amount})
+		if err != nil {
+			return VerifyResponse{}, err
+		}
+		leaf = hash
+	default:
+		return VerifyResponse{}, errors.New("request needs a leaf, or an address and amount")
+	}
+
+	var valid bool
+	if s.pairs == merkle.PositionalPairs {
+		if index < 0 {
+			return VerifyResponse{}, errors.New("positional trees need the index of the leaf")
+		}
+		valid = merkle.VerifyProofAt(request.Proof, root, leaf, index, s.artifact.EntryCount, s.opts...)
+	} else {
+		valid = merkle.VerifyProof(request.Proof, root, leaf, s.opts...)
+	}
+
+	return VerifyResponse{Valid: valid, Root: root, Leaf: leaf}, nil
+}
+
+// loadProofSnapshot reads the input file and builds the tree if it is a CSV
+func loadProofSnapshot(config ProofServerConfig) (*proofSnapshot, error) {
+	// Stat before reading, so a write during the load is picked up by the next check
+	info, err := os.Stat(config.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read distribution: %w", err)
+	}
+
+	var artifact *DistributionArtifact
+	if strings.EqualFold(filepath.Ext(config.Path), ".json") {
+		artifact, err = ReadDistributionArtifact(config.Path)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		encoder, err := NewLeafEncoder(config.Leaf)
+		if err != nil {
+			return nil, fmt.Errorf("invalid leaf configuration: %w", err)
+		}
+		testCases, report, err := ReadAllocations(config.Path, config.CSV)
+		if err != nil {
+			return nil, err
+		}
+		for _, invalid := range report.Invalid {
+			config.Logf("Skipping invalid row of %s, %s", config.Path, invalid)
+		}
+		for _, duplicate := range report.Duplicates.Addresses {
+			config.Logf("Duplicate address in %s (policy %s): %s", config.Path, config.CSV.Duplicates, duplicate)
+		}
+		distribution, err := BuildDistribution(testCases, encoder, config.Tree)
+		if err != nil {
+			return nil, err
+		}
+		artifact, err = distribution.Artifact()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	encoder, err := NewLeafEncoder(artifact.LeafEncoding)
+	if err != nil {
+		return nil, fmt.Errorf("invalid leaf encoding in distribution: %w", err)
+	}
+	// Artifacts written by hand may spell the names in any case
+	artifact.Tree, err = artifact.Tree.Normalized()
+	if err != nil {
+		return nil, fmt.Errorf("invalid tree configuration in distribution: %w", err)
+	}
+	opts, err := artifact.Tree.Options()
+	if err != nil {
+		return nil, fmt.Errorf("invalid tree configuration in distribution: %w", err)
+	}
+	pairs, err := merkle.PairStrategyByName(artifact.Tree.Pairs)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tree configuration in distribution: %w", err)
+	}
+
+	byAddress := make(map[common.Address]int, len(artifact.Entries))
+	for i, entry := range artifact.Entries {
+		if _, exists := byAddress[entry.Address]; !exists {
+			byAddress[entry.Address] = i
+		}
+	}
+
+	return &proofSnapshot{
+		artifact:  artifact,
+		byAddress: byAddress,
+		encoder:   encoder,
+		opts:      opts,
+		pairs:     pairs,
+		loadedAt:  time.Now().UTC(),
+		modTime:   info.ModTime(),
+		size:      info.Size(),
+	}, nil
+}
+
+// statusRecorder remembers the status code written by a handler
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (sr *statusRecorder) WriteHeader(status int) {
+	sr.status = status
+	sr.ResponseWriter.WriteHeader(status)
+}
+
+func writeJSONResponse(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(value)
+}
+
+func writeErrorResponse(w http.ResponseWriter, status int, message string) {
+	writeJSONResponse(w, status, errorResponse{Error: message})
+}
@@ -0,0 +1,167 @@
+package util
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const serverTestCSV = `address,amount
+0x742d35Cc6634C0532925A3B8D4C9dB96C4B4d8B6,100
+0x1111111111111111111111111111111111111111,200
+0x2222222222222222222222222222222222222222,300
+`
+
+func newTestProofServer(t *testing.T, csv string) (*ProofServer, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "allocations.csv")
+	if err := os.WriteFile(path, []byte(csv), 0o644); err != nil {
+		t.Fatalf("Failed to write CSV: %v", err)
+	}
+
+	server, err := NewProofServer(ProofServerConfig{Path: path, Logf: t.Logf})
+	if err != nil {
+		t.Fatalf("Failed to create proof server: %v", err)
+	}
+	return server, path
+}
+
+func TestProofServerProofAndVerify(t *testing.T) {
+	server, _ := newTestProofServer(t, serverTestCSV)
+	handler := server.Handler()
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/proof/0x1111111111111111111111111111111111111111", nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+
+	var proof ProofResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &proof); err != nil {
+		t.Fatalf("Invalid proof response: %v", err)
+	}
+	if proof.Root != server.Root() || proof.Index != 1 || proof.EntryCount != 3 || proof.Amount != "200" {
+		t.Errorf("Unexpected proof response: %+v", proof)
+	}
+
+	body, _ := json.Marshal(VerifyRequest{Address: &proof.Address, Amount: proof.Amount, Proof: proof.Proof})
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/verify", bytes.NewReader(body)))
+
+	var verify VerifyResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &verify); err != nil {
+		t.Fatalf("Invalid verify response: %v", err)
+	}
+	if !verify.Valid || verify.Leaf != proof.Leaf {
+		t.Errorf("Proof from /proof should verify, got %+v", verify)
+	}
+
+	// A different amount must not verify
+	body, _ = json.Marshal(VerifyRequest{Address: &proof.Address, Amount: "201", Proof: proof.Proof})
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/verify", bytes.NewReader(body)))
+	json.Unmarshal(recorder.Body.Bytes(), &verify)
+	if verify.Valid {
+		t.Error("Proof should not verify for a different amount")
+	}
+}
+
+func TestProofServerPositionalNameInAnyCase(t *testing.T) {
+	// An artifact written by hand, naming its pair strategy with a capital letter
+	distribution, err := BuildDistribution(distributionTestCases(), DefaultLeafEncoder(), TreeConfig{Pairs: "positional"})
+	if err != nil {
+		t.Fatalf("Failed to build distribution: %v", err)
+	}
+	var artifact bytes.Buffer
+	if err := distribution.WriteArtifact(&artifact); err != nil {
+		t.Fatalf("Failed to write artifact: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "distribution.json")
+	if err := os.WriteFile(path, bytes.Replace(artifact.Bytes(), []byte(`"positional"`), []byte(`"Positional"`), 1), 0o644); err != nil {
+		t.Fatalf("Failed to write artifact: %v", err)
+	}
+
+	server, err := NewProofServer(ProofServerConfig{Path: path, Logf: t.Logf})
+	if err != nil {
+		t.Fatalf("Failed to create proof server: %v", err)
+	}
+	handler := server.Handler()
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/proof/0x2222222222222222222222222222222222222222", nil))
+	var proof ProofResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &proof); err != nil {
+		t.Fatalf("Invalid proof response: %v", err)
+	}
+
+	body, _ := json.Marshal(VerifyRequest{Address: &proof.Address, Amount: proof.Amount, Index: &proof.Index, Proof: proof.Proof})
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/verify", bytes.NewReader(body)))
+	var verify VerifyResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &verify); err != nil {
+		t.Fatalf("Invalid verify response: %v", err)
+	}
+	if !verify.Valid {
+		t.Errorf("Positional proof from /proof should verify, got %+v", verify)
+	}
+}
+
+func TestProofServerErrors(t *testing.T) {
+	server, _ := newTestProofServer(t, serverTestCSV)
+	handler := server.Handler()
+
+	cases := []struct {
+		method string
+		path   string
+		status int
+	}{
+		{http.MethodGet, "/proof/0x3333333333333333333333333333333333333333", http.StatusNotFound},
+		{http.MethodGet, "/proof/not-an-address", http.StatusBadRequest},
+		{http.MethodGet, "/verify", http.StatusMethodNotAllowed},
+		{http.MethodPost, "/root", http.StatusMethodNotAllowed},
+	}
+	for _, c := range cases {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(c.method, c.path, nil))
+		if recorder.Code != c.status {
+			t.Errorf("%s %s: expected %d, got %d", c.method, c.path, c.status, recorder.Code)
+		}
+	}
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if !strings.Contains(recorder.Body.String(), `merkle_server_requests_total{handler="proof",code="404"} 1`) {
+		t.Errorf("Metrics should count the 404, got:\n%s", recorder.Body.String())
+	}
+}
+
+func TestProofServerReload(t *testing.T) {
+	server, path := newTestProofServer(t, serverTestCSV)
+	oldRoot := server.Root()
+
+	// An invalid file keeps the old tree
+	if err := os.WriteFile(path, []byte("address,amount\nnot-an-address,1\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write CSV: %v", err)
+	}
+	if err := server.Reload(); err == nil {
+		t.Error("Expected reload of an invalid CSV to fail")
+	}
+	if server.Root() != oldRoot {
+		t.Error("Failed reload should keep serving the old root")
+	}
+
+	if err := os.WriteFile(path, []byte(serverTestCSV+"0x3333333333333333333333333333333333333333,400\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write CSV: %v", err)
+	}
+	if err := server.Reload(); err != nil {
+		t.Fatalf("Unexpected reload error: %v", err)
+	}
+	if server.Root() == oldRoot {
+		t.Error("Reload should serve the new root")
+	}
+}
@@ -0,0 +1,128 @@
+// Package util provides sharded static proof exports
+package util
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// ShardManifestFile is the name of the manifest written next to the shard files
+const ShardManifestFile = "index.json"
+
+// DefaultShardPrefixLength shards by the first byte of the address, e.g. proofs/0x3a.json
+const DefaultShardPrefixLength = 2
+
+// ShardManifest describes a sharded proof export
+// A frontend reads the manifest once, then fetches the shard named by the first
+// PrefixLength hex digits of the lowercase address.
+type ShardManifest struct {
+	Root         common.Hash          `json:"root"`
+	Tree         TreeConfig           `json:"tree"`
+	LeafEncoding LeafConfig           `json:"leafEncoding"`
+	TotalAmount  string               `json:"totalAmount"`
+	EntryCount   int                  `json:"entryCount"`
+	PrefixLength int                  `json:"prefixLength"`
+	Shards       map[string]ShardInfo `json:"shards"`
+}
+
+// ShardInfo is a shard listed in the manifest
+type ShardInfo struct {
+	File       string      `json:"file"`
+	Hash       common.Hash `json:"hash"`
+	EntryCount int         `json:"entryCount"`
+}
+
+// Shard is the content of one shard file, keyed by lowercase address
+type Shard struct {
+	Root    common.Hash                  `json:"root"`
+	Entries map[string]DistributionEntry `json:"entries"`
+}
+
+// ShardKey returns the shard an address belongs to, e.g. "0x3a" for a prefix length of 2
+func ShardKey(address common.Address, prefixLength int) string {
+	return strings.ToLower(address.Hex()[:2+prefixLength])
+}
+
+// ExportShards writes every recipient's proof into address-prefix shard files in dir, followed by
+// the manifest holding the root and the keccak256 hash of each shard file.
+// Proofs are generated one shard at a time. Each address must appear only once.
+func (d *Distribution) ExportShards(dir string, prefixLength int) (*ShardManifest, error) {
+	if prefixLength < 1 || prefixLength > 2*common.AddressLength {
+		return nil, fmt.Errorf("shard prefix length must be between 1 and %d hex digits, got %d", 2*common.AddressLength, prefixLength)
+	}
+
+	// Group entry indices by shard, checking for repeated addresses
+	groups := make(map[string][]int)
+	seen := make(map[common.Address]int, len(d.TestCases))
+	for i, testCase := range d.TestCases {
+		if first, exists := seen[testCase.Address]; exists {
+			return nil, fmt.Errorf("address %s appears in entries %d and %d, shards need one entry per address",
+				testCase.Address.Hex(), first, i)
+		}
+		seen[testCase.Address] = i
+
+		key := ShardKey(testCase.Address, prefixLength)
+		groups[key] = append(groups[key], i)
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create shard directory: %w", err)
+	}
+
+	manifest := &ShardManifest{
+		Root:         d.MerkleData.Root,
+		Tree:         d.Tree,
+		LeafEncoding: d.MerkleData.Encoder.Config(),
+		TotalAmount:  d.TotalAmount.String(),
+		EntryCount:   len(d.TestCases),
+		PrefixLength: prefixLength,
+		Shards:       make(map[string]ShardInfo, len(groups)),
+	}
+
+	for key, indices := range groups {
+		shard := Shard{
+			Root:    d.MerkleData.Root,
+			Entries: make(map[string]DistributionEntry, len(indices)),
+		}
+		for _, index := range indices {
+			entry, err := d.Entry(index)
+			if err != nil {
+				return nil, err
+			}
+			shard.Entries[strings.ToLower(entry.Address.Hex())] = entry
+		}
+
+		// Map keys are sorted when encoded, so the same distribution always produces the same files
+		content, err := json.Marshal(shard)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode shard %s: %w", key, err)
+		}
+
+		file := key + ".json"
+		if err := os.WriteFile(filepath.Join(dir, file), content, 0o644); err != nil {
+			return nil, fmt.Errorf("failed to write shard %s: %w", key, err)
+		}
+		manifest.Shards[key] = ShardInfo{
+			File:       file,
+			Hash:       crypto.Keccak256Hash(content),
+			EntryCount: len(indices),
+		}
+	}
+
+	// Write the manifest last, so it only lists shards that are complete
+	content, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode shard manifest: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ShardManifestFile), append(content, '\n'), 0o644); err != nil {
+		return nil, fmt.Errorf("failed to write shard manifest: %w", err)
+	}
+
+	return manifest, nil
+}
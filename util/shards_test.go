@@ -0,0 +1,90 @@
+package util
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"merkle-generator/merkle"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestExportShards(t *testing.T) {
+	distribution, err := BuildDistribution(distributionTestCases(), DefaultLeafEncoder(), TreeConfig{})
+	if err != nil {
+		t.Fatalf("Failed to build distribution: %v", err)
+	}
+
+	dir := t.TempDir()
+	manifest, err := distribution.ExportShards(dir, DefaultShardPrefixLength)
+	if err != nil {
+		t.Fatalf("Failed to export shards: %v", err)
+	}
+
+	// 0x742d..., 0x1111... and 0x2222... land in three different shards
+	if len(manifest.Shards) != 3 || manifest.EntryCount != 3 {
+		t.Fatalf("Expected 3 shards and 3 entries, got %d and %d", len(manifest.Shards), manifest.EntryCount)
+	}
+
+	var written ShardManifest
+	content, err := os.ReadFile(filepath.Join(dir, ShardManifestFile))
+	if err != nil {
+		t.Fatalf("Manifest not written: %v", err)
+	}
+	if err := json.Unmarshal(content, &written); err != nil || written.Root != distribution.MerkleData.Root {
+		t.Fatalf("Manifest should hold the root, got %+v (%v)", written, err)
+	}
+
+	for _, testCase := range distributionTestCases() {
+		key := ShardKey(testCase.Address, DefaultShardPrefixLength)
+		info, ok := written.Shards[key]
+		if !ok {
+			t.Fatalf("No shard %s for %s", key, testCase.Address.Hex())
+		}
+
+		content, err := os.ReadFile(filepath.Join(dir, info.File))
+		if err != nil {
+			t.Fatalf("Shard %s not written: %v", key, err)
+		}
+		if crypto.Keccak256Hash(content) != info.Hash {
+			t.Errorf("Shard %s hash does not match the manifest", key)
+		}
+
+		var shard Shard
+		if err := json.Unmarshal(content, &shard); err != nil {
+			t.Fatalf("Invalid shard %s: %v", key, err)
+		}
+		entry, ok := shard.Entries[strings.ToLower(testCase.Address.Hex())]
+		if !ok {
+			t.Fatalf("Shard %s has no entry for %s", key, testCase.Address.Hex())
+		}
+		if !merkle.VerifyProof(entry.Proof, written.Root, entry.Leaf) {
+			t.Errorf("Proof for %s should verify", testCase.Address.Hex())
+		}
+	}
+}
+
+func TestExportShardsRejectsDuplicates(t *testing.T) {
+	testCases := append(distributionTestCases(), distributionTestCases()[0])
+	distribution, err := BuildDistribution(testCases, DefaultLeafEncoder(), TreeConfig{})
+	if err != nil {
+		t.Fatalf("Failed to build distribution: %v", err)
+	}
+	if _, err := distribution.ExportShards(t.TempDir(), DefaultShardPrefixLength); err == nil {
+		t.Error("Expected error for a repeated address")
+	}
+}
+
+func TestShardKey(t *testing.T) {
+	address := common.HexToAddress("0x3A8b7C6a8c3f4E8bD6e2C0b1a9F7e6D5c4B3a291")
+	if key := ShardKey(address, 2); key != "0x3a" {
+		t.Errorf("Expected 0x3a, got %s", key)
+	}
+	if key := ShardKey(address, 3); key != "0x3a8" {
+		t.Errorf("Expected 0x3a8, got %s", key)
+	}
+}
@@ -0,0 +1,127 @@
+// Package util provides transaction signers for raw keys, keystore files and mnemonics
+package util
+
+import (
+	"crypto/ecdsa"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Signer signs transactions for one address
+type Signer interface {
+	Address() common.Address
+	SignTx(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error)
+}
+
+// keySigner signs with a private key held in memory
+type keySigner struct {
+	key     *ecdsa.PrivateKey
+	address common.Address
+}
+
+// NewKeySigner returns a Signer for a parsed private key
+func NewKeySigner(key *ecdsa.PrivateKey) Signer {
+	return &keySigner{key: key, address: crypto.PubkeyToAddress(key.PublicKey)}
+}
+
+// parsePrivateKey parses a hex private key with or without 0x, never echoing it in errors
+func parsePrivateKey(hexKey string) (*ecdsa.PrivateKey, error) {
+	key, err := crypto.HexToECDSA(removeHexPrefix(strings.TrimSpace(hexKey)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid private key")
+	}
+	return key, nil
+}
+
+// NewRawKeySigner returns a Signer for a hex private key, with or without 0x
+func NewRawKeySigner(hexKey string) (Signer, error) {
+	key, err := parsePrivateKey(hexKey)
+	if err != nil {
+		return nil, err
+	}
+	return NewKeySigner(key), nil
+}
+
+func (s *keySigner) Address() common.Address {
+	return s.address
+}
+
+// SignTx signs legacy and dynamic fee transactions alike, with the latest signer for the chain
+func (s *keySigner) SignTx(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return types.SignTx(tx, types.LatestSignerForChainID(chainID), s.key)
+}
+
+// NewKeystoreSigner decrypts a V3 keystore file with its passphrase
+func NewKeystoreSigner(path, passphrase string) (Signer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read keystore file: %w", err)
+	}
+	key, err := DecryptKeystore(data, passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return NewKeySigner(key), nil
+}
+
+// keystoreSigner signs for the address a keystore file declares, decrypting the file the first time
+// it is unlocked or signs
+type keystoreSigner struct {
+	path       string
+	passphrase string
+	address    common.Address
+
+	once   sync.Once
+	signer Signer
+	err    error
+}
+
+func (s *keystoreSigner) unlock() (Signer, error) {
+	s.once.Do(func() {
+		data, err := os.ReadFile(s.path)
+		if err != nil {
+			s.err = fmt.Errorf("failed to read keystore file: %w", err)
+			return
+		}
+		key, err := DecryptKeystore(data, s.passphrase)
+		if err != nil {
+			s.err = fmt.Errorf("%s: %w", s.path, err)
+			return
+		}
+		s.signer = NewKeySigner(key)
+	})
+	return s.signer, s.err
+}
+
+func (s *keystoreSigner) Address() common.Address {
+	return s.address
+}
+
+func (s *keystoreSigner) SignTx(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	signer, err := s.unlock()
+	if err != nil {
+		return nil, err
+	}
+	return signer.SignTx(tx, chainID)
+}
+
+// NewMnemonicSigner derives the key at an HD path, e.g. m/44'/60'/0'/0/0, from a BIP-39 mnemonic
+// and its optional passphrase
+func NewMnemonicSigner(mnemonic, passphrase, path string) (Signer, error) {
+	seed, err := MnemonicToSeed(mnemonic, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	key, err := DeriveHDKey(seed, path)
+	if err != nil {
+		return nil, err
+	}
+	return NewKeySigner(key), nil
+}
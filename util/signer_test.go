@@ -0,0 +1,103 @@
+package util
+
+import (
+	"encoding/hex"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const testMnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+
+func TestMnemonicToSeed(t *testing.T) {
+	// BIP-39 test vector
+	seed, err := MnemonicToSeed(testMnemonic, "TREZOR")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := "c55257c360c07c72029aebc1b53c05ed0362ada38ead3e3e9efa3708e53495531f09a6987599d18264c1e1c92f2cf141630c7a3c4ab7c81b2f001698e7463b04"
+	if got := hex.EncodeToString(seed); got != expected {
+		t.Errorf("Unexpected seed %s", got)
+	}
+
+	if _, err := MnemonicToSeed("abandon about", ""); err == nil {
+		t.Error("Expected an error for a two word mnemonic")
+	}
+	// One mistyped word: the last word carries the checksum
+	if _, err := MnemonicToSeed(strings.Repeat("abandon ", 12), ""); err == nil || !strings.Contains(err.Error(), "checksum") {
+		t.Errorf("Expected a checksum error, got %v", err)
+	}
+	if _, err := MnemonicToSeed(strings.Replace(testMnemonic, "about", "abuot", 1), ""); err == nil || !strings.Contains(err.Error(), "word 12") {
+		t.Errorf("Expected an unknown word error, got %v", err)
+	}
+}
+
+func TestMnemonicSigner(t *testing.T) {
+	signer, err := NewMnemonicSigner(testMnemonic, "", DefaultHDPath)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if expected := common.HexToAddress("0x9858EfFD232B4033E47d90003D41EC34EcaEda94"); signer.Address() != expected {
+		t.Errorf("Expected %s, got %s", expected.Hex(), signer.Address().Hex())
+	}
+
+	if _, err := NewMnemonicSigner(testMnemonic, "", "m/44'/60'/x"); err == nil {
+		t.Error("Expected an error for an invalid HD path")
+	}
+}
+
+func TestMnemonicSigners(t *testing.T) {
+	set, err := NewMnemonicSigners(testMnemonic, "", DefaultHDPath, 2)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, address := range []string{"0x9858EfFD232B4033E47d90003D41EC34EcaEda94", "0x6Fac4D18c912343BF86fa7049364Dd4E424Ab9C0"} {
+		if _, err := set.Signer(common.HexToAddress(address)); err != nil {
+			t.Errorf("Expected a signer for %s, got %v", address, err)
+		}
+	}
+}
+
+func TestSignerSignsForItsAddress(t *testing.T) {
+	key, _ := crypto.HexToECDSA(testKeyHex)
+	path := filepath.Join(t.TempDir(), "keystore.json")
+	os.WriteFile(path, encryptTestKeystore(t, key, "secret"), 0o600)
+
+	raw, err := NewRawKeySigner("0x" + testKeyHex)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	keystore, err := NewKeystoreSigner(path, "secret")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := NewKeystoreSigner(path, "wrong"); err == nil {
+		t.Error("Expected an error for a wrong passphrase")
+	}
+
+	chainID := big.NewInt(1)
+	legacy := types.NewTransaction(0, common.Address{}, big.NewInt(0), 21000, big.NewInt(1), nil)
+	dynamic := types.NewTx(&types.DynamicFeeTx{ChainID: chainID, Gas: 21000, GasTipCap: big.NewInt(1), GasFeeCap: big.NewInt(2), To: &common.Address{}})
+	lazy := &keystoreSigner{path: path, passphrase: "secret", address: crypto.PubkeyToAddress(key.PublicKey)}
+	for _, signer := range []Signer{raw, keystore, lazy} {
+		if signer.Address() != crypto.PubkeyToAddress(key.PublicKey) {
+			t.Errorf("Unexpected signer address %s", signer.Address().Hex())
+		}
+		for _, tx := range []*types.Transaction{legacy, dynamic} {
+			signed, err := signer.SignTx(tx, chainID)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			sender, err := types.Sender(types.LatestSignerForChainID(chainID), signed)
+			if err != nil || sender != signer.Address() {
+				t.Errorf("Expected the type %d transaction to be signed by %s, got %s (%v)", tx.Type(), signer.Address().Hex(), sender.Hex(), err)
+			}
+		}
+	}
+}